@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	_ "time/tzdata" // База часовых поясов для образов без tzdata
+
+	"Eldarius_bot/internal/bot"
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/config"
+	"Eldarius_bot/internal/logging"
+	"Eldarius_bot/internal/storage"
+	"Eldarius_bot/internal/telegram"
+)
+
+func main() {
+	// Загружаем конфигурацию
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Ошибка загрузки конфигурации", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// Создаем журнал с уровнем и форматом из конфигурации
+	logger, err := logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		slog.Error("Ошибка создания журнала", slog.Any("error", err))
+		os.Exit(1)
+	}
+	slog.SetDefault(logger)
+	telegram.SetLibraryLogger(logger)
+
+	// Открываем хранилище и применяем миграции схемы
+	store, err := storage.NewSQLite(cfg.DatabasePath, clock.Real{}, logger)
+	if err != nil {
+		logger.Error("Ошибка открытия хранилища", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// Создаем сервис бота
+	service, err := bot.NewService(cfg, store, clock.Real{}, logger)
+	if err != nil {
+		store.Close()
+		logger.Error("Ошибка создания сервиса", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// Запускаем бота до получения сигнала завершения
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if err := service.Start(ctx); err != nil {
+		logger.Error("Ошибка работы сервиса", slog.Any("error", err))
+	}
+	// Повторный сигнал завершает процесс сразу, не дожидаясь остановки
+	stop()
+	logger.Info("Остановка сервиса", slog.Duration("timeout", cfg.ShutdownTimeout))
+
+	// Дожидаемся начатой работы и закрываем хранилище
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	err = service.Stop(shutdownCtx)
+	cancel()
+	if err != nil {
+		logger.Error("Ошибка остановки сервиса", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
@@ -6,9 +6,9 @@ CREATE TABLE IF NOT EXISTS groups (
 
 CREATE TABLE IF NOT EXISTS birthdays (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
-    first_name TEXT NOT NULL,
-    last_name TEXT NOT NULL,
+    name TEXT NOT NULL,
     birthday DATE NOT NULL,
+    year_unknown INTEGER NOT NULL DEFAULT 0,
     group_id INTEGER NOT NULL,
     FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
 );
@@ -30,47 +30,47 @@ INSERT INTO groups (id, title) VALUES (-1001932668989, 'Birthday Group');
 -- Добавляем настройки по умолчанию (уведомления в 09:00)
 INSERT INTO settings (group_id, notify_time) VALUES (-1001932668989, '09:00');
 
--- Добавляем дни рождения
-INSERT INTO birthdays (first_name, last_name, birthday, group_id) VALUES
-    ('Эдуард', 'Джендубаев', '2000-01-02', -1001932668989),
-    ('Теодор', 'Джендубаев', '2000-01-08', -1001932668989),
-    ('Роберт', 'Джендубаев', '2000-01-11', -1001932668989),
-    ('Анна', 'Джендубаева', '2000-01-25', -1001932668989),
-    ('Зураб', 'Джендубаев', '2000-01-27', -1001932668989),
-    ('Демис', 'Полантис', '2000-02-05', -1001932668989),
-    ('Альберт', 'Марчануков', '2000-03-02', -1001932668989),
-    ('Ислам', 'Кнухов', '2000-03-08', -1001932668989),
-    ('Белла', 'Джендубаева', '2000-03-15', -1001932668989),
-    ('Джамал', 'Кнухов', '2000-03-19', -1001932668989),
-    ('Дамир', 'Джендубаев', '2000-03-19', -1001932668989),
-    ('Адам', 'Дагужиев', '2000-04-01', -1001932668989),
-    ('Азамат', 'Джендубаев', '2000-04-22', -1001932668989),
-    ('Шахимби', 'Дагужиев', '2000-05-08', -1001932668989),
-    ('Закария', 'Полантис', '2000-05-14', -1001932668989),
-    ('Алина', 'Кнухова', '2000-05-28', -1001932668989),
-    ('Аза', 'Марчанукова', '2000-05-31', -1001932668989),
-    ('Данияр', 'Джендубаев', '2000-06-05', -1001932668989),
-    ('Сафарби', 'Кнухов', '2000-06-11', -1001932668989),
-    ('Аслан', 'Джендубаев', '2000-06-14', -1001932668989),
-    ('Артур', 'Марчануков', '2000-06-16', -1001932668989),
-    ('Аскар', 'Шанов', '2000-06-26', -1001932668989),
-    ('Диана', 'Джендубаева', '2000-06-27', -1001932668989),
-    ('Александра', 'Джендубаева', '2000-07-09', -1001932668989),
-    ('Роман', 'Кнухов', '2000-07-15', -1001932668989),
-    ('Армида', 'Дагужиева', '2000-08-08', -1001932668989),
-    ('Инал', 'Дагужиев', '2000-08-19', -1001932668989),
-    ('Фатима', 'Джендубаева', '2000-09-02', -1001932668989),
-    ('Аюб', 'Дагужиев', '2000-09-05', -1001932668989),
-    ('Индар', 'Джендубаев', '2000-09-08', -1001932668989),
-    ('Татьяна', 'Кнухова', '2000-09-19', -1001932668989),
-    ('Эльдар', 'Джендубаев', '2000-10-02', -1001932668989),
-    ('Эмир', 'Чагов', '2000-10-09', -1001932668989),
-    ('Самира', 'Джендубаева', '2000-10-27', -1001932668989),
-    ('Ариза', 'Кнухова', '2000-10-28', -1001932668989),
-    ('Залина', 'Дагужиева', '2000-11-26', -1001932668989),
-    ('Ксения', 'Джендубаева', '2000-11-30', -1001932668989),
-    ('Заур', 'Джендубаев', '2000-12-10', -1001932668989),
-    ('Аделина', 'Марчанукова', '2000-12-22', -1001932668989),
-    ('Тамина', 'Джендубаева', '2000-12-22', -1001932668989),
-    ('Светлана', 'Джендубаева', '2000-12-23', -1001932668989),
-    ('Фуад', 'Гедегулов', '2000-12-29', -1001932668989); 
\ No newline at end of file
+-- Добавляем дни рождения. Известны только день и месяц, поэтому год — заглушка 2000
+INSERT INTO birthdays (name, birthday, year_unknown, group_id) VALUES
+    ('Эдуард Джендубаев', '2000-01-02', 1, -1001932668989),
+    ('Теодор Джендубаев', '2000-01-08', 1, -1001932668989),
+    ('Роберт Джендубаев', '2000-01-11', 1, -1001932668989),
+    ('Анна Джендубаева', '2000-01-25', 1, -1001932668989),
+    ('Зураб Джендубаев', '2000-01-27', 1, -1001932668989),
+    ('Демис Полантис', '2000-02-05', 1, -1001932668989),
+    ('Альберт Марчануков', '2000-03-02', 1, -1001932668989),
+    ('Ислам Кнухов', '2000-03-08', 1, -1001932668989),
+    ('Белла Джендубаева', '2000-03-15', 1, -1001932668989),
+    ('Джамал Кнухов', '2000-03-19', 1, -1001932668989),
+    ('Дамир Джендубаев', '2000-03-19', 1, -1001932668989),
+    ('Адам Дагужиев', '2000-04-01', 1, -1001932668989),
+    ('Азамат Джендубаев', '2000-04-22', 1, -1001932668989),
+    ('Шахимби Дагужиев', '2000-05-08', 1, -1001932668989),
+    ('Закария Полантис', '2000-05-14', 1, -1001932668989),
+    ('Алина Кнухова', '2000-05-28', 1, -1001932668989),
+    ('Аза Марчанукова', '2000-05-31', 1, -1001932668989),
+    ('Данияр Джендубаев', '2000-06-05', 1, -1001932668989),
+    ('Сафарби Кнухов', '2000-06-11', 1, -1001932668989),
+    ('Аслан Джендубаев', '2000-06-14', 1, -1001932668989),
+    ('Артур Марчануков', '2000-06-16', 1, -1001932668989),
+    ('Аскар Шанов', '2000-06-26', 1, -1001932668989),
+    ('Диана Джендубаева', '2000-06-27', 1, -1001932668989),
+    ('Александра Джендубаева', '2000-07-09', 1, -1001932668989),
+    ('Роман Кнухов', '2000-07-15', 1, -1001932668989),
+    ('Армида Дагужиева', '2000-08-08', 1, -1001932668989),
+    ('Инал Дагужиев', '2000-08-19', 1, -1001932668989),
+    ('Фатима Джендубаева', '2000-09-02', 1, -1001932668989),
+    ('Аюб Дагужиев', '2000-09-05', 1, -1001932668989),
+    ('Индар Джендубаев', '2000-09-08', 1, -1001932668989),
+    ('Татьяна Кнухова', '2000-09-19', 1, -1001932668989),
+    ('Эльдар Джендубаев', '2000-10-02', 1, -1001932668989),
+    ('Эмир Чагов', '2000-10-09', 1, -1001932668989),
+    ('Самира Джендубаева', '2000-10-27', 1, -1001932668989),
+    ('Ариза Кнухова', '2000-10-28', 1, -1001932668989),
+    ('Залина Дагужиева', '2000-11-26', 1, -1001932668989),
+    ('Ксения Джендубаева', '2000-11-30', 1, -1001932668989),
+    ('Заур Джендубаев', '2000-12-10', 1, -1001932668989),
+    ('Аделина Марчанукова', '2000-12-22', 1, -1001932668989),
+    ('Тамина Джендубаева', '2000-12-22', 1, -1001932668989),
+    ('Светлана Джендубаева', '2000-12-23', 1, -1001932668989),
+    ('Фуад Гедегулов', '2000-12-29', 1, -1001932668989); 
\ No newline at end of file
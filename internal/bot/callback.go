@@ -0,0 +1,181 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"Eldarius_bot/internal/i18n"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Данные кнопок кодируются компактно: "<версия>:<действие>[:<аргумент>...]",
+// например "1:d:42" — удалить запись с ID 42. Аргументы — только числа (ID и номера страниц),
+// поэтому данные всегда укладываются в лимит Telegram в 64 байта, независимо от имен.
+
+// callbackVersion версия формата данных кнопок.
+// При несовместимом изменении действий версия увеличивается, а старые кнопки считаются устаревшими.
+const callbackVersion = "1"
+
+// maxCallbackDataLength ограничение Telegram на размер данных кнопки в байтах
+const maxCallbackDataLength = 64
+
+// Действия кнопок
+const (
+	actionShow         = "s"  // Список дней рождения, аргумент — страница
+	actionAdd          = "a"  // Добавление дня рождения
+	actionDeleteList   = "dl" // Клавиатура удаления, аргумент — страница
+	actionDelete       = "d"  // Удаление записи, аргументы — ID записи и страница клавиатуры
+	actionDeleteByName = "dn" // Удаление по имени через диалог
+	actionEditList     = "el" // Клавиатура изменения, аргумент — страница
+	actionEdit         = "e"  // Выбор поля для изменения, аргумент — ID записи
+	actionEditName     = "en" // Изменение имени, аргумент — ID записи
+	actionEditDate     = "ed" // Изменение даты, аргумент — ID записи
+	actionEditSave     = "es" // Сохранение изменений
+	actionEditCancel   = "ec" // Отмена изменений
+	actionNoop         = "n"  // Кнопка без действия (номер страницы)
+)
+
+// errUnknownCallback возвращается для данных кнопок неизвестного или устаревшего формата
+var errUnknownCallback = errors.New("неизвестный формат данных кнопки")
+
+// callbackData разобранные данные кнопки
+type callbackData struct {
+	Action string
+	Args   []int64
+}
+
+// encodeCallback кодирует действие и числовые аргументы в данные кнопки
+func encodeCallback(action string, args ...int64) string {
+	var b strings.Builder
+	b.WriteString(callbackVersion)
+	b.WriteString(":")
+	b.WriteString(action)
+	for _, arg := range args {
+		b.WriteString(":")
+		b.WriteString(strconv.FormatInt(arg, 10))
+	}
+	return b.String()
+}
+
+// decodeCallback разбирает данные кнопки. Кнопки старого формата
+// (например, delete_name_<имя>) возвращают errUnknownCallback.
+func decodeCallback(data string) (callbackData, error) {
+	if len(data) > maxCallbackDataLength {
+		return callbackData{}, errUnknownCallback
+	}
+
+	parts := strings.Split(data, ":")
+	if len(parts) < 2 || parts[0] != callbackVersion || parts[1] == "" {
+		return callbackData{}, errUnknownCallback
+	}
+
+	cb := callbackData{Action: parts[1]}
+	for _, p := range parts[2:] {
+		arg, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return callbackData{}, fmt.Errorf("%w: %s", errUnknownCallback, data)
+		}
+		cb.Args = append(cb.Args, arg)
+	}
+
+	return cb, nil
+}
+
+// arg возвращает i-й аргумент кнопки или 0, если его нет
+func (c callbackData) arg(i int) int64 {
+	if i < len(c.Args) {
+		return c.Args[i]
+	}
+	return 0
+}
+
+// mutating проверяет, изменяет ли действие кнопки данные группы
+func (c callbackData) mutating() bool {
+	switch c.Action {
+	case actionAdd, actionDeleteList, actionDelete, actionDeleteByName,
+		actionEditList, actionEdit, actionEditName, actionEditDate, actionEditSave, actionEditCancel:
+		return true
+	}
+	return false
+}
+
+// keyboardPageSize количество записей на одной странице клавиатуры
+const keyboardPageSize = 8
+
+// pageBounds возвращает границы страницы page в списке из total элементов,
+// исправленный номер страницы и общее количество страниц
+func pageBounds(total, page, size int) (start, end, fixedPage, pages int) {
+	pages = max(1, (total+size-1)/size)
+	fixedPage = min(max(page, 0), pages-1)
+	start = fixedPage * size
+	end = min(start+size, total)
+	return start, end, fixedPage, pages
+}
+
+// navigationRow возвращает ряд кнопок переключения страниц или nil, если страница одна
+func navigationRow(l i18n.Localizer, action string, page, pages int) []tgbotapi.InlineKeyboardButton {
+	if pages <= 1 {
+		return nil
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(l.T("nav.prev"), encodeCallback(action, int64(page-1))))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d/%d", page+1, pages), encodeCallback(actionNoop)))
+	if page < pages-1 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(l.T("nav.next"), encodeCallback(action, int64(page+1))))
+	}
+	return row
+}
+
+// maxMessageLength ограничение Telegram на длину текста сообщения.
+// Проверяется по байтам: это не меньше длины в символах, поэтому с запасом.
+const maxMessageLength = 4096
+
+// listPageSize максимальное количество строк на одной странице списка
+const listPageSize = 30
+
+// splitPages делит строки списка на страницы так, чтобы каждая страница
+// вместе с заголовком и кнопками укладывалась в лимит длины сообщения
+func splitPages(header string, lines []string) [][]string {
+	limit := maxMessageLength - len(header) - 64 // Запас на строку с номером страницы
+
+	var pages [][]string
+	var page []string
+	size := 0
+	for _, line := range lines {
+		if len(page) > 0 && (len(page) >= listPageSize || size+len(line)+1 > limit) {
+			pages = append(pages, page)
+			page, size = nil, 0
+		}
+		page = append(page, line)
+		size += len(line) + 1
+	}
+	if len(page) > 0 {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// sendOrEdit отправляет новое сообщение, если messageID равен 0,
+// иначе заменяет текст и клавиатуру существующего сообщения
+func (h *Handler) sendOrEdit(ctx context.Context, chatID int64, messageID int, text string, markup *tgbotapi.InlineKeyboardMarkup) error {
+	if messageID == 0 {
+		msg := tgbotapi.NewMessage(chatID, text)
+		if markup != nil {
+			msg.ReplyMarkup = *markup
+		}
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	edit.ReplyMarkup = markup
+	_, err := h.send(ctx, edit)
+	return err
+}
@@ -0,0 +1,270 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"Eldarius_bot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// conversationTimeout время, после которого незавершенный диалог считается брошенным
+const conversationTimeout = 10 * time.Minute
+
+// Сценарии пошаговых диалогов
+const (
+	flowAdd    = "add"    // Добавление дня рождения
+	flowEdit   = "edit"   // Изменение дня рождения
+	flowDelete = "delete" // Удаление дня рождения по имени
+)
+
+// Шаги пошаговых диалогов
+const (
+	stepName    = "name"    // Ввод имени
+	stepDate    = "date"    // Ввод даты рождения
+	stepPick    = "pick"    // Выбор существующей записи по имени
+	stepConfirm = "confirm" // Подтверждение действия
+)
+
+// Форматы ввода даты рождения: полный и без года, если год неизвестен
+const (
+	dateLayout       = "02.01.2006"
+	dateLayoutNoYear = "02.01"
+)
+
+// startConversation начинает новый диалог пользователя, заменяя незавершенный, и задает первый вопрос
+func (h *Handler) startConversation(ctx context.Context, chatID, userID int64, flow, step, prompt string) error {
+	conv := &models.Conversation{
+		ChatID: chatID,
+		UserID: userID,
+		Flow:   flow,
+		Data:   map[string]string{},
+	}
+	return h.advanceConversation(ctx, conv, step, prompt)
+}
+
+// advanceConversation переводит диалог на следующий шаг и задает вопрос
+func (h *Handler) advanceConversation(ctx context.Context, conv *models.Conversation, step, prompt string) error {
+	conv.Step = step
+	if err := h.store.SaveConversation(ctx, conv); err != nil {
+		return fmt.Errorf("ошибка сохранения диалога: %w", err)
+	}
+	return h.sendPrompt(ctx, conv.ChatID, prompt)
+}
+
+// finishConversation завершает диалог и отправляет итоговое сообщение
+func (h *Handler) finishConversation(ctx context.Context, conv *models.Conversation, text string) error {
+	if err := h.store.DeleteConversation(ctx, conv.ChatID, conv.UserID); err != nil {
+		return fmt.Errorf("ошибка завершения диалога: %w", err)
+	}
+	msg := tgbotapi.NewMessage(conv.ChatID, text)
+	_, err := h.send(ctx, msg)
+	return err
+}
+
+// sendPrompt задает вопрос диалога. ForceReply открывает ответ на сообщение бота,
+// поэтому ответ доходит до бота и в группах с включенным режимом приватности.
+func (h *Handler) sendPrompt(ctx context.Context, chatID int64, text string) error {
+	msg := tgbotapi.NewMessage(chatID, text+"\n\n"+h.localizer(ctx, chatID).T("conversation.cancel"))
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true}
+	_, err := h.send(ctx, msg)
+	return err
+}
+
+// loadConversation возвращает активный диалог пользователя или nil.
+// Просроченные диалоги удаляются.
+func (h *Handler) loadConversation(ctx context.Context, chatID, userID int64) (*models.Conversation, error) {
+	conv, err := h.store.GetConversation(ctx, chatID, userID)
+	if err != nil || conv == nil {
+		return nil, err
+	}
+
+	if h.clock.Now().Sub(conv.UpdatedAt) > conversationTimeout {
+		if err := h.store.DeleteConversation(ctx, chatID, userID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	return conv, nil
+}
+
+// handleCancel отменяет активный диалог пользователя
+func (h *Handler) handleCancel(ctx context.Context, message *tgbotapi.Message) error {
+	userID, ok := conversationUserID(message)
+	if !ok {
+		return h.rejectAnonymous(ctx, message.Chat.ID)
+	}
+
+	conv, err := h.loadConversation(ctx, message.Chat.ID, userID)
+	if err != nil {
+		return err
+	}
+
+	l := h.localizer(ctx, message.Chat.ID)
+	if conv == nil {
+		msg := tgbotapi.NewMessage(message.Chat.ID, l.T("conversation.none"))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	return h.finishConversation(ctx, conv, l.T("conversation.cancelled"))
+}
+
+// handleConversation обрабатывает ответ пользователя на текущем шаге диалога
+func (h *Handler) handleConversation(ctx context.Context, message *tgbotapi.Message, conv *models.Conversation) error {
+	text := strings.TrimSpace(message.Text)
+	if text == "" {
+		return h.sendPrompt(ctx, conv.ChatID, h.localizer(ctx, conv.ChatID).T("answer.text_only"))
+	}
+
+	switch conv.Flow + "/" + conv.Step {
+	case flowAdd + "/" + stepName:
+		return h.addStepName(ctx, conv, text)
+	case flowAdd + "/" + stepDate:
+		return h.addStepDate(ctx, conv, text)
+	case flowDelete + "/" + stepPick:
+		return h.deleteStepPick(ctx, conv, text)
+	case flowDelete + "/" + stepConfirm:
+		return h.deleteStepConfirm(ctx, conv, text)
+	case flowEdit + "/" + stepName:
+		return h.editStepName(ctx, conv, text)
+	case flowEdit + "/" + stepDate:
+		return h.editStepDate(ctx, conv, text)
+	case flowEdit + "/" + stepConfirm:
+		return h.editStepConfirm(ctx, conv, text)
+	default:
+		// Состояние от несовместимой версии бота: просто сбрасываем его
+		return h.store.DeleteConversation(ctx, conv.ChatID, conv.UserID)
+	}
+}
+
+// addStepName принимает имя. Если в ответе сразу указана дата, запись сохраняется без следующего шага.
+func (h *Handler) addStepName(ctx context.Context, conv *models.Conversation, text string) error {
+	if name, birthday, yearUnknown, ok := parseBirthdayLine(text); ok {
+		return h.saveNewBirthday(ctx, conv, name, birthday, yearUnknown)
+	}
+
+	l := h.localizer(ctx, conv.ChatID)
+	conv.Data["name"] = text
+	return h.advanceConversation(ctx, conv, stepDate, l.T("add.date_prompt", text, l.T("date.hint")))
+}
+
+// addStepDate принимает дату рождения и сохраняет запись
+func (h *Handler) addStepDate(ctx context.Context, conv *models.Conversation, text string) error {
+	birthday, yearUnknown, err := parseBirthdayDate(text)
+	if err != nil {
+		l := h.localizer(ctx, conv.ChatID)
+		return h.sendPrompt(ctx, conv.ChatID, l.T("add.bad_date", l.T("date.hint")))
+	}
+
+	return h.saveNewBirthday(ctx, conv, conv.Data["name"], birthday, yearUnknown)
+}
+
+// saveNewBirthday сохраняет новую запись и завершает диалог добавления
+func (h *Handler) saveNewBirthday(ctx context.Context, conv *models.Conversation, name string, birthday time.Time, yearUnknown bool) error {
+	b := &models.Birthday{
+		Name:        name,
+		Birthday:    birthday,
+		YearUnknown: yearUnknown,
+		GroupID:     conv.ChatID,
+	}
+
+	l := h.localizer(ctx, conv.ChatID)
+	if err := h.store.AddBirthday(ctx, b); err != nil {
+		return h.finishConversation(ctx, conv, l.T("add.error", h.errorText(ctx, l, err)))
+	}
+
+	return h.finishConversation(ctx, conv, l.T("add.done", name))
+}
+
+// deleteStepPick находит запись для удаления по имени и запрашивает подтверждение
+func (h *Handler) deleteStepPick(ctx context.Context, conv *models.Conversation, text string) error {
+	b, err := h.findBirthdayByName(ctx, conv.ChatID, text)
+	if err != nil {
+		return err
+	}
+	l := h.localizer(ctx, conv.ChatID)
+	if b == nil {
+		return h.sendPrompt(ctx, conv.ChatID, l.T("delete.name_notfound"))
+	}
+
+	conv.Data["id"] = strconv.FormatInt(b.ID, 10)
+	conv.Data["name"] = b.Name
+	return h.advanceConversation(ctx, conv, stepConfirm, l.T("delete.confirm", b.Name, b.FormatDate()))
+}
+
+// deleteStepConfirm удаляет запись после подтверждения
+func (h *Handler) deleteStepConfirm(ctx context.Context, conv *models.Conversation, text string) error {
+	l := h.localizer(ctx, conv.ChatID)
+
+	switch strings.ToLower(text) {
+	case "да", "yes":
+	case "нет", "no":
+		return h.finishConversation(ctx, conv, l.T("delete.cancelled"))
+	default:
+		return h.sendPrompt(ctx, conv.ChatID, l.T("answer.yes_no"))
+	}
+
+	id, err := strconv.ParseInt(conv.Data["id"], 10, 64)
+	if err != nil {
+		return h.finishConversation(ctx, conv, l.T("delete.not_found"))
+	}
+
+	if err := h.store.DeleteBirthday(ctx, conv.ChatID, id); err != nil {
+		return h.finishConversation(ctx, conv, l.T("delete.error", h.errorText(ctx, l, err)))
+	}
+
+	return h.finishConversation(ctx, conv, l.T("delete.done", conv.Data["name"]))
+}
+
+// findBirthdayByName ищет запись группы по имени без учета регистра
+func (h *Handler) findBirthdayByName(ctx context.Context, chatID int64, name string) (*models.Birthday, error) {
+	birthdays, err := h.store.GetBirthdays(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении дней рождения: %w", err)
+	}
+
+	for _, b := range birthdays {
+		if strings.EqualFold(b.Name, name) {
+			return b, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// parseBirthdayDate разбирает дату рождения в формате ДД.ММ.ГГГГ или ДД.ММ.
+// Для даты без года подставляется models.UnknownYear и возвращается yearUnknown.
+func parseBirthdayDate(text string) (birthday time.Time, yearUnknown bool, err error) {
+	if birthday, err := time.Parse(dateLayout, text); err == nil {
+		return birthday, false, nil
+	}
+
+	date, err := time.Parse(dateLayoutNoYear, text)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("неверный формат даты: %s", text)
+	}
+	// time.Parse без года возвращает нулевой год: переносим день и месяц на год-заглушку
+	birthday = time.Date(models.UnknownYear, date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return birthday, true, nil
+}
+
+// parseBirthdayLine разбирает строку вида "Имя Фамилия ДД.ММ.ГГГГ" или "Имя Фамилия ДД.ММ"
+func parseBirthdayLine(text string) (name string, birthday time.Time, yearUnknown bool, ok bool) {
+	parts := strings.Fields(text)
+	if len(parts) < 2 {
+		return "", time.Time{}, false, false
+	}
+
+	birthday, yearUnknown, err := parseBirthdayDate(parts[len(parts)-1])
+	if err != nil {
+		return "", time.Time{}, false, false
+	}
+
+	return strings.Join(parts[:len(parts)-1], " "), birthday, yearUnknown, true
+}
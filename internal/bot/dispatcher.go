@@ -0,0 +1,86 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"Eldarius_bot/internal/metrics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// dispatcher распределяет обновления между обработчиками по чату. Обновления разных чатов
+// обрабатываются параллельно, а одного чата всегда попадают к одному обработчику
+// и обрабатываются по очереди в порядке получения.
+type dispatcher struct {
+	handle UpdateHandler
+	queues []chan tgbotapi.Update
+	wg     sync.WaitGroup
+}
+
+// newDispatcher создает workers обработчиков с очередями по queueSize обновлений
+func newDispatcher(handle UpdateHandler, workers, queueSize int) *dispatcher {
+	d := &dispatcher{
+		handle: handle,
+		queues: make([]chan tgbotapi.Update, workers),
+	}
+	for i := range d.queues {
+		d.queues[i] = make(chan tgbotapi.Update, queueSize)
+	}
+	return d
+}
+
+// start запускает обработчики. Обновления обрабатываются с контекстом ctx.
+func (d *dispatcher) start(ctx context.Context) {
+	for i, queue := range d.queues {
+		worker := strconv.Itoa(i)
+		metrics.UpdateQueue.WithLabelValues(worker).Set(0)
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for update := range queue {
+				metrics.UpdateQueue.WithLabelValues(worker).Dec()
+				// Ошибки уже записаны в журнал middleware Logging
+				_ = d.handle(ctx, &update)
+			}
+		}()
+	}
+}
+
+// dispatch ставит обновление в очередь обработчика его чата. Если очередь заполнена,
+// ждет освобождения места: прием новых обновлений замедляется, но они не теряются.
+func (d *dispatcher) dispatch(update tgbotapi.Update) {
+	i := d.shard(&update)
+	worker := strconv.Itoa(i)
+
+	metrics.UpdateQueue.WithLabelValues(worker).Inc()
+	select {
+	case d.queues[i] <- update:
+	default:
+		metrics.UpdateQueueFull.WithLabelValues(worker).Inc()
+		d.queues[i] <- update
+	}
+}
+
+// shard возвращает номер обработчика для обновления. Обновления без чата распределяются
+// по пользователю, чтобы нажатия одного пользователя тоже шли по порядку.
+func (d *dispatcher) shard(update *tgbotapi.Update) int {
+	var key int64
+	if chat := updateChat(update); chat != nil {
+		key = chat.ID
+	} else if user := update.SentFrom(); user != nil {
+		key = user.ID
+	}
+	return int(uint64(key) % uint64(len(d.queues)))
+}
+
+// close перестает принимать обновления и ждет, пока обработчики разберут свои очереди.
+// После close вызывать dispatch нельзя.
+func (d *dispatcher) close() {
+	for _, queue := range d.queues {
+		close(queue)
+	}
+	d.wg.Wait()
+}
@@ -0,0 +1,85 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// chatUpdate возвращает обновление с сообщением number в чат chatID
+func chatUpdate(chatID int64, number int) tgbotapi.Update {
+	return tgbotapi.Update{
+		UpdateID: number,
+		Message: &tgbotapi.Message{
+			MessageID: number,
+			Chat:      &tgbotapi.Chat{ID: chatID},
+		},
+	}
+}
+
+func TestDispatcherKeepsChatOrder(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		seen = map[int64][]int{}
+	)
+	d := newDispatcher(func(ctx context.Context, update *tgbotapi.Update) error {
+		mu.Lock()
+		defer mu.Unlock()
+		chatID := update.Message.Chat.ID
+		seen[chatID] = append(seen[chatID], update.Message.MessageID)
+		return nil
+	}, 4, 2)
+	d.start(context.Background())
+
+	chats := []int64{-1001, -1002, 42, 7}
+	const perChat = 50
+	for i := 0; i < perChat; i++ {
+		for _, chatID := range chats {
+			d.dispatch(chatUpdate(chatID, i))
+		}
+	}
+	d.close()
+
+	for _, chatID := range chats {
+		got := seen[chatID]
+		if len(got) != perChat {
+			t.Fatalf("чат %d: обработано %d обновлений, ожидалось %d", chatID, len(got), perChat)
+		}
+		for i, number := range got {
+			if number != i {
+				t.Fatalf("чат %d: обновления обработаны не по порядку: %v", chatID, got)
+			}
+		}
+	}
+}
+
+func TestDispatcherParallelChats(t *testing.T) {
+	slow, fast := int64(1), int64(2) // При двух обработчиках попадают к разным
+	release := make(chan struct{})
+	fastDone := make(chan struct{})
+
+	d := newDispatcher(func(ctx context.Context, update *tgbotapi.Update) error {
+		switch update.Message.Chat.ID {
+		case slow:
+			<-release
+		case fast:
+			close(fastDone)
+		}
+		return nil
+	}, 2, 1)
+	d.start(context.Background())
+	defer d.close()
+	defer close(release)
+
+	d.dispatch(chatUpdate(slow, 1))
+	d.dispatch(chatUpdate(fast, 1))
+
+	select {
+	case <-fastDone:
+	case <-time.After(5 * time.Second):
+		t.Fatal("медленный чат задержал обработку другого чата")
+	}
+}
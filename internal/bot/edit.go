@@ -0,0 +1,235 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"Eldarius_bot/internal/i18n"
+	"Eldarius_bot/internal/models"
+	"Eldarius_bot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleEditBirthday показывает страницу клавиатуры выбора записи для изменения.
+// Если messageID не равен 0, клавиатура заменяет содержимое этого сообщения.
+func (h *Handler) handleEditBirthday(ctx context.Context, chatID int64, messageID, page int) error {
+	l := h.localizer(ctx, chatID)
+
+	birthdays, err := h.store.GetBirthdays(ctx, chatID)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, l.T("list.error", h.errorText(ctx, l, err)))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	if len(birthdays) == 0 {
+		return h.sendOrEdit(ctx, chatID, messageID, l.T("list.empty"), nil)
+	}
+
+	start, end, page, pages := pageBounds(len(birthdays), page, keyboardPageSize)
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, b := range birthdays[start:end] {
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(
+				l.T("edit.button", b.Name, b.FormatDate()),
+				encodeCallback(actionEdit, b.ID),
+			),
+		))
+	}
+	if nav := navigationRow(l, actionEditList, page, pages); nav != nil {
+		keyboard = append(keyboard, nav)
+	}
+
+	markup := tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	return h.sendOrEdit(ctx, chatID, messageID, l.T("edit.keyboard"), &markup)
+}
+
+// handleEditCallback обрабатывает кнопки диалога изменения дня рождения
+func (h *Handler) handleEditCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, data callbackData) error {
+	chatID := callback.Message.Chat.ID
+	l := h.localizer(ctx, chatID)
+
+	switch data.Action {
+	case actionEditList:
+		// Кнопка главного меню открывает клавиатуру новым сообщением, навигация листает текущую
+		messageID := callback.Message.MessageID
+		if len(data.Args) == 0 {
+			messageID = 0
+		}
+		return h.handleEditBirthday(ctx, chatID, messageID, int(data.arg(0)))
+	case actionEditSave:
+		return h.handleEditSave(ctx, chatID, callback.From.ID)
+	case actionEditCancel:
+		conv, err := h.loadConversation(ctx, chatID, callback.From.ID)
+		if err != nil || conv == nil || conv.Flow != flowEdit {
+			return err
+		}
+		return h.finishConversation(ctx, conv, l.T("edit.cancelled"))
+	}
+
+	// Остальные кнопки содержат ID записи
+	b, err := h.store.GetBirthday(ctx, chatID, data.arg(0))
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, l.T("delete.not_found"))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	switch data.Action {
+	case actionEdit:
+		return h.sendEditFieldChoice(ctx, l, b)
+	case actionEditName:
+		return h.startEditConversation(ctx, b, callback.From.ID, stepName,
+			l.T("edit.name_prompt", b.Name))
+	case actionEditDate:
+		return h.startEditConversation(ctx, b, callback.From.ID, stepDate,
+			l.T("edit.date_prompt", b.Name, b.FormatDate(), l.T("date.hint")))
+	default:
+		return fmt.Errorf("неизвестный callback: %s", callback.Data)
+	}
+}
+
+// sendEditFieldChoice предлагает выбрать, что изменить в записи
+func (h *Handler) sendEditFieldChoice(ctx context.Context, l i18n.Localizer, b *models.Birthday) error {
+	msg := tgbotapi.NewMessage(b.GroupID, l.T("edit.choose_field", b.Name, b.FormatDate()))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(l.T("edit.field_name"), encodeCallback(actionEditName, b.ID)),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("edit.field_date"), encodeCallback(actionEditDate, b.ID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.cancel"), encodeCallback(actionEditCancel)),
+		),
+	)
+	_, err := h.send(ctx, msg)
+	return err
+}
+
+// startEditConversation начинает диалог ввода нового значения поля записи
+func (h *Handler) startEditConversation(ctx context.Context, b *models.Birthday, userID int64, step, prompt string) error {
+	conv := &models.Conversation{
+		ChatID: b.GroupID,
+		UserID: userID,
+		Flow:   flowEdit,
+		Data: map[string]string{
+			"id":   strconv.FormatInt(b.ID, 10),
+			"name": b.Name,
+			"date": b.FormatDate(),
+			"old":  fmt.Sprintf("%s (%s)", b.Name, b.FormatDate()),
+		},
+	}
+	return h.advanceConversation(ctx, conv, step, prompt)
+}
+
+// editStepName принимает новое имя
+func (h *Handler) editStepName(ctx context.Context, conv *models.Conversation, text string) error {
+	conv.Data["name"] = text
+	return h.confirmEdit(ctx, conv)
+}
+
+// editStepDate принимает новую дату рождения
+func (h *Handler) editStepDate(ctx context.Context, conv *models.Conversation, text string) error {
+	if _, _, err := parseBirthdayDate(text); err != nil {
+		l := h.localizer(ctx, conv.ChatID)
+		return h.sendPrompt(ctx, conv.ChatID, l.T("add.bad_date", l.T("date.hint")))
+	}
+	conv.Data["date"] = text
+	return h.confirmEdit(ctx, conv)
+}
+
+// editStepConfirm принимает текстовое подтверждение вместо нажатия на кнопку
+func (h *Handler) editStepConfirm(ctx context.Context, conv *models.Conversation, text string) error {
+	l := h.localizer(ctx, conv.ChatID)
+
+	switch strings.ToLower(text) {
+	case "да", "yes":
+		return h.saveEdit(ctx, conv)
+	case "нет", "no":
+		return h.finishConversation(ctx, conv, l.T("edit.cancelled"))
+	default:
+		return h.sendPrompt(ctx, conv.ChatID, l.T("answer.yes_no"))
+	}
+}
+
+// editedBirthday собирает измененную запись из данных диалога
+func editedBirthday(conv *models.Conversation) (*models.Birthday, error) {
+	id, err := strconv.ParseInt(conv.Data["id"], 10, 64)
+	if err != nil {
+		return nil, storage.ErrBirthdayNotFound
+	}
+	birthday, yearUnknown, err := parseBirthdayDate(conv.Data["date"])
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Birthday{
+		ID:          id,
+		Name:        conv.Data["name"],
+		Birthday:    birthday,
+		YearUnknown: yearUnknown,
+		GroupID:     conv.ChatID,
+	}, nil
+}
+
+// confirmEdit проверяет измененную запись и просит подтвердить сохранение
+func (h *Handler) confirmEdit(ctx context.Context, conv *models.Conversation) error {
+	l := h.localizer(ctx, conv.ChatID)
+
+	b, err := editedBirthday(conv)
+	if err == nil {
+		err = b.Validate(h.clock.Now())
+	}
+	if err != nil {
+		// Оставляем пользователя на текущем шаге, чтобы он исправил значение
+		return h.sendPrompt(ctx, conv.ChatID, l.T("edit.retry", h.errorText(ctx, l, err)))
+	}
+
+	conv.Step = stepConfirm
+	if err := h.store.SaveConversation(ctx, conv); err != nil {
+		return fmt.Errorf("ошибка сохранения диалога: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(conv.ChatID, l.T("edit.confirm", conv.Data["old"], b.Name, b.FormatDate()))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.save"), encodeCallback(actionEditSave)),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("button.cancel"), encodeCallback(actionEditCancel)),
+		),
+	)
+	_, err = h.send(ctx, msg)
+	return err
+}
+
+// handleEditSave сохраняет изменения по нажатию на кнопку подтверждения
+func (h *Handler) handleEditSave(ctx context.Context, chatID, userID int64) error {
+	conv, err := h.loadConversation(ctx, chatID, userID)
+	if err != nil {
+		return err
+	}
+
+	if conv == nil || conv.Flow != flowEdit || conv.Step != stepConfirm {
+		msg := tgbotapi.NewMessage(chatID, h.localizer(ctx, chatID).T("edit.expired"))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	return h.saveEdit(ctx, conv)
+}
+
+// saveEdit записывает изменения и завершает диалог
+func (h *Handler) saveEdit(ctx context.Context, conv *models.Conversation) error {
+	l := h.localizer(ctx, conv.ChatID)
+
+	b, err := editedBirthday(conv)
+	if err == nil {
+		err = h.store.UpdateBirthday(ctx, b)
+	}
+	if err != nil {
+		return h.finishConversation(ctx, conv, l.T("edit.error", h.errorText(ctx, l, err)))
+	}
+
+	return h.finishConversation(ctx, conv, l.T("edit.done", b.Name, b.FormatDate()))
+}
@@ -0,0 +1,143 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"Eldarius_bot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// greetingPreviewLength сколько символов поздравления показывать в списке
+const greetingPreviewLength = 80
+
+// handleGreetings показывает и изменяет библиотеку поздравлений группы
+func (h *Handler) handleGreetings(ctx context.Context, message *tgbotapi.Message) error {
+	chatID := message.Chat.ID
+	l := h.localizer(ctx, chatID)
+	action, rest := cutWord(message.CommandArguments())
+
+	if action == "" {
+		return h.sendGreetingList(ctx, chatID)
+	}
+
+	if action != "add" && action != "remove" {
+		msg := tgbotapi.NewMessage(chatID, l.T("greetings.usage"))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	// Изменять библиотеку могут только администраторы и редакторы
+	if ok, err := h.requireEditor(ctx, message); !ok {
+		return err
+	}
+
+	var text string
+	if action == "add" {
+		greeting := greetingFromMessage(message, rest)
+		if greeting.Text == "" && greeting.FileID == "" {
+			msg := tgbotapi.NewMessage(chatID, l.T("greetings.usage"))
+			_, err := h.send(ctx, msg)
+			return err
+		}
+
+		if err := h.store.AddGreeting(ctx, greeting); err != nil {
+			text = l.T("greetings.add_error", h.errorText(ctx, l, err))
+		} else {
+			text = l.T("greetings.added", greeting.ID)
+		}
+	} else {
+		id, err := strconv.ParseInt(rest, 10, 64)
+		if err != nil {
+			msg := tgbotapi.NewMessage(chatID, l.T("greetings.usage"))
+			_, err := h.send(ctx, msg)
+			return err
+		}
+
+		text = l.T("greetings.removed", id)
+		if err := h.store.DeleteGreeting(ctx, chatID, id); err != nil {
+			text = l.T("greetings.remove_error", h.errorText(ctx, l, err))
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	_, err := h.send(ctx, msg)
+	return err
+}
+
+// greetingFromMessage собирает поздравление из текста команды и сообщения, на которое она отвечает:
+// из ответа берутся стикер или GIF, а если текст в команде не указан — и текст
+func greetingFromMessage(message *tgbotapi.Message, text string) *models.Greeting {
+	greeting := &models.Greeting{GroupID: message.Chat.ID, Text: text}
+
+	if reply := message.ReplyToMessage; reply != nil {
+		switch {
+		case reply.Sticker != nil:
+			greeting.MediaType, greeting.FileID = models.MediaSticker, reply.Sticker.FileID
+		case reply.Animation != nil:
+			greeting.MediaType, greeting.FileID = models.MediaAnimation, reply.Animation.FileID
+		}
+		if greeting.Text == "" {
+			greeting.Text = strings.TrimSpace(reply.Text + reply.Caption)
+		}
+	}
+
+	return greeting
+}
+
+// sendGreetingList показывает поздравления, из которых бот выбирает поздравление имениннику
+func (h *Handler) sendGreetingList(ctx context.Context, chatID int64) error {
+	l := h.localizer(ctx, chatID)
+
+	greetings, err := h.store.GetGreetings(ctx, chatID, string(l.Lang()))
+	if err != nil {
+		return fmt.Errorf("ошибка при получении поздравлений: %w", err)
+	}
+
+	var lines []string
+	for _, g := range greetings {
+		line := fmt.Sprintf("№%d %s", g.ID, truncate(g.Text, greetingPreviewLength))
+		switch g.MediaType {
+		case models.MediaSticker:
+			line += l.T("greetings.sticker")
+		case models.MediaAnimation:
+			line += l.T("greetings.animation")
+		}
+		if g.Builtin() {
+			line += l.T("greetings.builtin")
+		}
+		lines = append(lines, line)
+	}
+
+	header := l.T("greetings.header") + "\n\n"
+	footer := "\n\n" + l.T("greetings.usage")
+	// Список ограничен лимитом поздравлений группы, но на всякий случай не выходим за размер сообщения
+	pages := splitPages(header+footer, lines)
+	for i, page := range pages {
+		text := strings.Join(page, "\n")
+		if i == 0 {
+			text = header + text
+		}
+		if i == len(pages)-1 {
+			text += footer
+		}
+		msg := tgbotapi.NewMessage(chatID, text)
+		if _, err := h.send(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// truncate обрезает текст до limit символов
+func truncate(text string, limit int) string {
+	runes := []rune(strings.ReplaceAll(text, "\n", " "))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -3,47 +3,78 @@ package bot
 import (
 	"context"
 	"fmt"
-	"sort"
+	"log/slog"
+	"strconv"
 	"strings"
 	"time"
 
+	"Eldarius_bot/internal/calendar"
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/i18n"
+	"Eldarius_bot/internal/logging"
 	"Eldarius_bot/internal/models"
 	"Eldarius_bot/internal/storage"
+	"Eldarius_bot/internal/telegram"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 // Handler обрабатывает команды и сообщения от пользователей
 type Handler struct {
-	store storage.Repository
-	bot   *tgbotapi.BotAPI
+	store    storage.Repository
+	bot      telegram.Messenger
+	username string // Имя бота в Telegram без @, по нему распознаются упоминания
+	clock    clock.Clock
+	perms    *Permissions
+	logger   *slog.Logger
 }
 
 // NewHandler создает новый обработчик команд
-func NewHandler(store storage.Repository, bot *tgbotapi.BotAPI) *Handler {
+func NewHandler(store storage.Repository, bot telegram.Messenger, username string, clk clock.Clock, logger *slog.Logger) *Handler {
 	return &Handler{
-		store: store,
-		bot:   bot,
+		store:    store,
+		bot:      bot,
+		username: username,
+		clock:    clk,
+		perms:    NewPermissions(store, bot, clk),
+		logger:   logger,
 	}
 }
 
-// HandleUpdate обрабатывает обновление от Telegram
-func (h *Handler) HandleUpdate(update *tgbotapi.Update) error {
-	if update.Message == nil {
-		return nil
-	}
+// log возвращает журнал текущего обновления с его correlation_id, группой и пользователем
+func (h *Handler) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, h.logger)
+}
 
-	// Проверяем, упомянут ли бот
-	if !h.isBotMentioned(update.Message) {
-		return nil
+// send отправляет сообщение. Ожидание очереди отправки прерывается вместе с обработкой обновления,
+// когда истекает ее время (см. Timeout).
+func (h *Handler) send(ctx context.Context, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if m, ok := h.bot.(telegram.ContextMessenger); ok {
+		return m.SendContext(ctx, c)
 	}
+	return h.bot.Send(c)
+}
 
-	// Создаем контекст с таймаутом
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// request выполняет запрос к Bot API, пока не отменен ctx
+func (h *Handler) request(ctx context.Context, c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	if m, ok := h.bot.(telegram.ContextMessenger); ok {
+		return m.RequestContext(ctx, c)
+	}
+	return h.bot.Request(c)
+}
 
-	// Отправляем меню
-	return h.sendMainMenu(ctx, update.Message.Chat.ID)
+// HandleUpdate передает обновление от Telegram обработчику сообщения или нажатия на кнопку
+func (h *Handler) HandleUpdate(ctx context.Context, update *tgbotapi.Update) error {
+	switch {
+	case update.Message != nil:
+		return h.HandleMessage(ctx, update.Message)
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return h.HandleCallback(ctx, update.CallbackQuery)
+	case update.MyChatMember != nil:
+		return h.HandleMyChatMember(ctx, update.MyChatMember)
+	default:
+		return nil
+	}
 }
 
 // isBotMentioned проверяет, упомянут ли бот в сообщении
@@ -55,7 +86,7 @@ func (h *Handler) isBotMentioned(message *tgbotapi.Message) bool {
 	for _, entity := range message.Entities {
 		if entity.Type == "mention" {
 			mention := message.Text[entity.Offset : entity.Offset+entity.Length]
-			botUsername := "@" + h.bot.Self.UserName
+			botUsername := "@" + h.username
 			return mention == botUsername
 		}
 	}
@@ -64,212 +95,271 @@ func (h *Handler) isBotMentioned(message *tgbotapi.Message) bool {
 
 // sendMainMenu отправляет главное меню
 func (h *Handler) sendMainMenu(ctx context.Context, chatID int64) error {
-	msg := tgbotapi.NewMessage(chatID, "Выберите действие:")
+	return h.sendMenu(ctx, chatID, h.localizer(ctx, chatID).T("menu.prompt"))
+}
+
+// sendMenu отправляет главное меню с указанным текстом
+func (h *Handler) sendMenu(ctx context.Context, chatID int64, text string) error {
+	l := h.localizer(ctx, chatID)
+	msg := tgbotapi.NewMessage(chatID, text)
 	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("📅 Показать дни рождения", "show_birthdays"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("menu.show"), encodeCallback(actionShow)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("➕ Добавить день рождения", "add_birthday"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("menu.add"), encodeCallback(actionAdd)),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Удалить день рождения", "delete_birthday"),
+			tgbotapi.NewInlineKeyboardButtonData(l.T("menu.edit"), encodeCallback(actionEditList)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(l.T("menu.delete"), encodeCallback(actionDeleteList)),
 		),
 	)
-	_, err := h.bot.Send(msg)
+	_, err := h.send(ctx, msg)
 	return err
 }
 
 // HandleCallback обрабатывает нажатия на кнопки меню
-func (h *Handler) HandleCallback(callback *tgbotapi.CallbackQuery) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	// Обрабатываем нажатие на кнопку удаления
-	if strings.HasPrefix(callback.Data, "delete_name_") {
-		return h.handleDeleteBirthdayCallback(ctx, callback)
-	}
-
-	switch callback.Data {
-	case "show_birthdays":
-		return h.handleShowBirthdays(ctx, callback.Message.Chat.ID)
-	case "add_birthday":
-		return h.handleAddBirthday(ctx, callback.Message.Chat.ID)
-	case "delete_birthday":
-		return h.handleDeleteBirthday(ctx, callback.Message.Chat.ID)
+func (h *Handler) HandleCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) error {
+	chatID := callback.Message.Chat.ID
+	l := h.localizer(ctx, chatID)
+
+	// Кнопки старого формата остаются в истории чата: предлагаем открыть меню заново
+	data, err := decodeCallback(callback.Data)
+	if err != nil {
+		if _, err := h.request(ctx, tgbotapi.NewCallback(callback.ID, l.T("callback.outdated"))); err != nil {
+			return fmt.Errorf("ошибка ответа на callback: %w", err)
+		}
+		return h.sendMenu(ctx, chatID, l.T("callback.outdated_menu"))
+	}
+
+	// Кнопки, изменяющие данные, доступны только администраторам и редакторам
+	if data.mutating() {
+		ok, err := h.perms.CanEdit(ctx, callback.Message.Chat, callback.From.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			_, err := h.request(ctx, tgbotapi.NewCallbackWithAlert(callback.ID, l.T("access.denied")))
+			return err
+		}
+	}
+
+	// Отвечаем на callback query, чтобы убрать индикатор загрузки на кнопке
+	if _, err := h.request(ctx, tgbotapi.NewCallback(callback.ID, "")); err != nil {
+		return fmt.Errorf("ошибка ответа на callback: %w", err)
+	}
+
+	// Кнопки навигации заменяют содержимое сообщения, в котором нажаты
+	messageID := callback.Message.MessageID
+	page := int(data.arg(0))
+
+	switch data.Action {
+	case actionNoop:
+		return nil
+	case actionShow:
+		if len(data.Args) == 0 {
+			// Кнопка главного меню открывает список новым сообщением
+			messageID = 0
+		}
+		return h.handleShowBirthdays(ctx, chatID, messageID, page)
+	case actionAdd:
+		return h.handleAddBirthday(ctx, chatID, callback.From.ID)
+	case actionDeleteList:
+		if len(data.Args) == 0 {
+			messageID = 0
+		}
+		return h.handleDeleteBirthday(ctx, chatID, messageID, page)
+	case actionDelete:
+		return h.handleDeleteBirthdayCallback(ctx, callback, data.arg(0), int(data.arg(1)))
+	case actionDeleteByName:
+		return h.handleDeleteBirthdayByName(ctx, chatID, callback.From.ID)
+	case actionEditList, actionEdit, actionEditName, actionEditDate, actionEditSave, actionEditCancel:
+		return h.handleEditCallback(ctx, callback, data)
 	default:
 		return fmt.Errorf("неизвестный callback: %s", callback.Data)
 	}
 }
 
-// handleShowBirthdays показывает список дней рождения
-func (h *Handler) handleShowBirthdays(ctx context.Context, chatID int64) error {
+// handleShowBirthdays показывает страницу списка дней рождения.
+// Если messageID не равен 0, страница заменяет содержимое этого сообщения.
+func (h *Handler) handleShowBirthdays(ctx context.Context, chatID int64, messageID, page int) error {
+	l := h.localizer(ctx, chatID)
+
 	birthdays, err := h.store.GetBirthdays(ctx, chatID)
 	if err != nil {
 		return fmt.Errorf("ошибка при получении дней рождения: %w", err)
 	}
 
 	if len(birthdays) == 0 {
-		msg := tgbotapi.NewMessage(chatID, "В этой группе пока нет дней рождения.")
-		_, err := h.bot.Send(msg)
-		return err
+		return h.sendOrEdit(ctx, chatID, messageID, l.T("list.empty"), nil)
 	}
 
-	// Сортируем дни рождения по ближайшей дате
-	sort.Slice(birthdays, func(i, j int) bool {
-		now := time.Now()
-		nextBirthdayI := getNextBirthday(birthdays[i].Birthday, now)
-		nextBirthdayJ := getNextBirthday(birthdays[j].Birthday, now)
-		return nextBirthdayI.Before(nextBirthdayJ)
-	})
-
-	var text strings.Builder
-	text.WriteString("📅 Дни рождения в группе:\n\n")
-	for _, b := range birthdays {
-		nextBirthday := getNextBirthday(b.Birthday, time.Now())
-		daysUntil := int(nextBirthday.Sub(time.Now()).Hours() / 24)
-
-		if daysUntil == 0 {
-			text.WriteString(fmt.Sprintf("🎉 %s - СЕГОДНЯ! (%s)\n",
-				b.Name,
-				b.Birthday.Format("02.01.2006")))
+	// "Сегодня" определяется в часовом поясе группы
+	loc, err := h.store.GetTimezone(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении часового пояса: %w", err)
+	}
+	now := h.clock.Now().In(loc)
+
+	var lines []string
+	for _, o := range calendar.Sort(birthdays, now) {
+		b := o.Birthday
+
+		if o.DaysUntil == 0 {
+			lines = append(lines, l.T("list.today", b.Name, b.FormatDate()))
 		} else {
-			text.WriteString(fmt.Sprintf("🎂 %s - %d %s (%s)\n",
-				b.Name,
-				daysUntil,
-				getDaysWord(daysUntil),
-				b.Birthday.Format("02.01.2006")))
+			lines = append(lines, l.T("list.upcoming", b.Name, o.DaysUntil, l.Days(o.DaysUntil), b.FormatDate()))
 		}
 	}
 
-	msg := tgbotapi.NewMessage(chatID, text.String())
-	_, err = h.bot.Send(msg)
-	return err
-}
+	header := l.T("list.header") + "\n\n"
+	pages := splitPages(header, lines)
+	_, _, page, _ = pageBounds(len(pages), page, 1)
 
-// getNextBirthday вычисляет дату следующего дня рождения
-func getNextBirthday(birthday time.Time, now time.Time) time.Time {
-	nextBirthday := time.Date(now.Year(), birthday.Month(), birthday.Day(), 0, 0, 0, 0, time.Local)
-	if nextBirthday.Before(now) {
-		nextBirthday = nextBirthday.AddDate(1, 0, 0)
+	text := header + strings.Join(pages[page], "\n")
+	var markup *tgbotapi.InlineKeyboardMarkup
+	if nav := navigationRow(l, actionShow, page, len(pages)); nav != nil {
+		keyboard := tgbotapi.NewInlineKeyboardMarkup(nav)
+		markup = &keyboard
 	}
-	return nextBirthday
+
+	return h.sendOrEdit(ctx, chatID, messageID, text, markup)
 }
 
-// getDaysWord возвращает правильное склонение слова "день"
-func getDaysWord(days int) string {
-	if days%10 == 1 && days%100 != 11 {
-		return "день"
-	}
-	if days%10 >= 2 && days%10 <= 4 && (days%100 < 10 || days%100 >= 20) {
-		return "дня"
-	}
-	return "дней"
+// handleAddBirthday начинает диалог добавления дня рождения
+func (h *Handler) handleAddBirthday(ctx context.Context, chatID, userID int64) error {
+	return h.startConversation(ctx, chatID, userID, flowAdd, stepName,
+		h.localizer(ctx, chatID).T("add.prompt"))
 }
 
-// handleAddBirthday показывает меню добавления дня рождения
-func (h *Handler) handleAddBirthday(ctx context.Context, chatID int64) error {
-	msg := tgbotapi.NewMessage(chatID, "Введите имя, фамилию и дату рождения в формате:\nИмя Фамилия ДД.ММ.ГГГГ")
-	_, err := h.bot.Send(msg)
-	return err
+// handleDeleteBirthdayByName начинает диалог удаления дня рождения по имени
+func (h *Handler) handleDeleteBirthdayByName(ctx context.Context, chatID, userID int64) error {
+	return h.startConversation(ctx, chatID, userID, flowDelete, stepPick,
+		h.localizer(ctx, chatID).T("delete.name_prompt"))
 }
 
-// handleDeleteBirthday показывает меню удаления дня рождения
-func (h *Handler) handleDeleteBirthday(ctx context.Context, chatID int64) error {
+// handleDeleteBirthday показывает страницу клавиатуры удаления дня рождения.
+// Если messageID не равен 0, клавиатура заменяет содержимое этого сообщения.
+func (h *Handler) handleDeleteBirthday(ctx context.Context, chatID int64, messageID, page int) error {
+	l := h.localizer(ctx, chatID)
+
 	// Получаем список дней рождения
 	birthdays, err := h.store.GetBirthdays(ctx, chatID)
 	if err != nil {
-		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Ошибка при получении дней рождения: %v", err))
-		_, err := h.bot.Send(msg)
+		msg := tgbotapi.NewMessage(chatID, l.T("list.error", h.errorText(ctx, l, err)))
+		_, err := h.send(ctx, msg)
 		return err
 	}
 
 	if len(birthdays) == 0 {
-		msg := tgbotapi.NewMessage(chatID, "📝 В этой группе пока нет дней рождения.")
-		_, err := h.bot.Send(msg)
-		return err
+		return h.sendOrEdit(ctx, chatID, messageID, l.T("list.empty"), nil)
 	}
 
-	// Создаем клавиатуру со списком дней рождения
+	// Создаем клавиатуру с текущей страницей списка
+	start, end, page, pages := pageBounds(len(birthdays), page, keyboardPageSize)
 	var keyboard [][]tgbotapi.InlineKeyboardButton
-	for _, b := range birthdays {
+	for _, b := range birthdays[start:end] {
 		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData(
-				fmt.Sprintf("❌ %s (%s)", b.Name, b.Birthday.Format("02.01.2006")),
-				fmt.Sprintf("delete_name_%s", b.Name),
+				l.T("delete.button", b.Name, b.FormatDate()),
+				encodeCallback(actionDelete, b.ID, int64(page)),
 			),
 		))
 	}
+	if nav := navigationRow(l, actionDeleteList, page, pages); nav != nil {
+		keyboard = append(keyboard, nav)
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(l.T("delete.manual"), encodeCallback(actionDeleteByName)),
+	))
 
-	msg := tgbotapi.NewMessage(chatID, "🗑 Выберите день рождения для удаления из списка:")
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
-	_, err = h.bot.Send(msg)
-	return err
+	markup := tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	return h.sendOrEdit(ctx, chatID, messageID, l.T("delete.keyboard"), &markup)
 }
 
-// handleDeleteBirthdayCallback обрабатывает нажатие на кнопку удаления дня рождения
-func (h *Handler) handleDeleteBirthdayCallback(ctx context.Context, callback *tgbotapi.CallbackQuery) error {
-	// Извлекаем имя из callback data
-	name := strings.TrimPrefix(callback.Data, "delete_name_")
+// handleDeleteBirthdayCallback удаляет день рождения по ID из кнопки
+// и обновляет клавиатуру, с которой было нажатие
+func (h *Handler) handleDeleteBirthdayCallback(ctx context.Context, callback *tgbotapi.CallbackQuery, id int64, page int) error {
+	chatID := callback.Message.Chat.ID
+	l := h.localizer(ctx, chatID)
 
-	// Получаем список дней рождения
-	birthdays, err := h.store.GetBirthdays(ctx, callback.Message.Chat.ID)
+	// Запись могли уже удалить с другой кнопки или командой
+	b, err := h.store.GetBirthday(ctx, chatID, id)
 	if err != nil {
-		msg := tgbotapi.NewMessage(callback.Message.Chat.ID, fmt.Sprintf("❌ Ошибка при получении дней рождения: %v", err))
-		_, err := h.bot.Send(msg)
+		msg := tgbotapi.NewMessage(chatID, l.T("delete.not_found"))
+		_, err := h.send(ctx, msg)
 		return err
 	}
 
-	// Ищем день рождения по имени
-	var foundBirthday *models.Birthday
-	for _, b := range birthdays {
-		if b.Name == name {
-			foundBirthday = b
-			break
-		}
-	}
-
-	if foundBirthday == nil {
-		msg := tgbotapi.NewMessage(callback.Message.Chat.ID, "❌ День рождения не найден")
-		_, err := h.bot.Send(msg)
+	// Удаляем день рождения
+	if err := h.store.DeleteBirthday(ctx, chatID, b.ID); err != nil {
+		msg := tgbotapi.NewMessage(chatID, l.T("delete.error", h.errorText(ctx, l, err)))
+		_, err := h.send(ctx, msg)
 		return err
 	}
 
-	// Удаляем день рождения
-	if err := h.store.DeleteBirthday(ctx, callback.Message.Chat.ID, foundBirthday.ID); err != nil {
-		msg := tgbotapi.NewMessage(callback.Message.Chat.ID, fmt.Sprintf("❌ Ошибка при удалении дня рождения: %v", err))
-		_, err := h.bot.Send(msg)
+	// Отправляем подтверждение
+	msg := tgbotapi.NewMessage(chatID, l.T("delete.done", b.Name))
+	if _, err := h.send(ctx, msg); err != nil {
 		return err
 	}
 
-	// Отправляем подтверждение
-	msg := tgbotapi.NewMessage(callback.Message.Chat.ID, fmt.Sprintf("✅ День рождения %s успешно удален!", foundBirthday.Name))
-	_, err = h.bot.Send(msg)
-	return err
+	return h.handleDeleteBirthday(ctx, chatID, callback.Message.MessageID, page)
 }
 
 // HandleMessage обрабатывает текстовые сообщения
-func (h *Handler) HandleMessage(message *tgbotapi.Message) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+func (h *Handler) HandleMessage(ctx context.Context, message *tgbotapi.Message) error {
+	// Группа стала супергруппой и сменила идентификатор
+	if message.MigrateToChatID != 0 {
+		return h.handleMigration(ctx, message)
+	}
 
 	// Проверяем, является ли сообщение командой
 	if message.IsCommand() {
+		l := h.localizer(ctx, message.Chat.ID)
+
 		switch message.Command() {
 		case "start":
-			return h.sendMainMenu(ctx, message.Chat.ID)
+			return h.sendMenu(ctx, message.Chat.ID, l.T("start.greeting")+"\n\n"+l.T("menu.prompt"))
 		case "help":
-			helpText := `Доступные команды:
-/start - Показать главное меню
-/help - Показать это сообщение
-/remind - Напомнить о днях рождения
-
-Также вы можете упомянуть бота (@username) для вызова меню.`
-			msg := tgbotapi.NewMessage(message.Chat.ID, helpText)
-			_, err := h.bot.Send(msg)
+			msg := tgbotapi.NewMessage(message.Chat.ID, l.T("help.text"))
+			_, err := h.send(ctx, msg)
 			return err
-		case "remind":
-			return h.handleShowBirthdays(ctx, message.Chat.ID)
+		case "remind", "list":
+			return h.handleShowBirthdays(ctx, message.Chat.ID, 0, 0)
+		case "add", "edit", "delete":
+			// Изменять дни рождения могут только администраторы и редакторы
+			if ok, err := h.requireEditor(ctx, message); !ok {
+				return err
+			}
+			switch message.Command() {
+			case "add":
+				userID, ok := conversationUserID(message)
+				if !ok {
+					return h.rejectAnonymous(ctx, message.Chat.ID)
+				}
+				return h.handleAddBirthday(ctx, message.Chat.ID, userID)
+			case "edit":
+				return h.handleEditBirthday(ctx, message.Chat.ID, 0, 0)
+			default:
+				return h.handleDeleteBirthday(ctx, message.Chat.ID, 0, 0)
+			}
+		case "cancel":
+			return h.handleCancel(ctx, message)
+		case "timezone":
+			return h.handleTimezone(ctx, message)
+		case "reminders":
+			return h.handleReminders(ctx, message)
+		case "editors":
+			return h.handleEditors(ctx, message)
+		case "template":
+			return h.handleTemplate(ctx, message)
+		case "greetings":
+			return h.handleGreetings(ctx, message)
+		case "language":
+			return h.handleLanguage(ctx, message)
 		}
 		return nil
 	}
@@ -279,94 +369,270 @@ func (h *Handler) HandleMessage(message *tgbotapi.Message) error {
 		return h.sendMainMenu(ctx, message.Chat.ID)
 	}
 
-	// Проверяем, является ли сообщение ответом на запрос добавления дня рождения
-	if message.ReplyToMessage != nil && message.ReplyToMessage.Text == "Введите имя, фамилию и дату рождения в формате:\nИмя Фамилия ДД.ММ.ГГГГ" {
-		return h.processAddBirthday(ctx, message)
+	// Продолжаем пошаговый диалог пользователя, если он есть
+	if message.From == nil {
+		return nil
+	}
+
+	userID, ok := conversationUserID(message)
+	if !ok {
+		return nil
 	}
 
-	// Проверяем, является ли сообщение ответом на запрос удаления дня рождения
-	if message.ReplyToMessage != nil && strings.Contains(message.ReplyToMessage.Text, "Введите имя и фамилию человека, чей день рождения нужно удалить") {
-		return h.processDeleteBirthdayByName(ctx, message)
+	conv, err := h.loadConversation(ctx, message.Chat.ID, userID)
+	if err != nil {
+		return err
+	}
+	if conv != nil {
+		return h.handleConversation(ctx, message, conv)
 	}
 
 	return nil
 }
 
-// processAddBirthday обрабатывает добавление дня рождения
-func (h *Handler) processAddBirthday(ctx context.Context, message *tgbotapi.Message) error {
-	parts := strings.Fields(message.Text)
-	if len(parts) < 3 {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Неверный формат. Используйте: Имя Фамилия ДД.ММ.ГГГГ")
-		_, err := h.bot.Send(msg)
-		return err
+// conversationUserID возвращает ключ диалога отправителя сообщения: ID пользователя
+// или ID канала, от имени которого написано сообщение. Сообщения анонимных администраторов
+// приходят от одного служебного аккаунта и от имени самой группы, поэтому различить их нельзя:
+// для них и для сообщений без отправителя возвращается false.
+func conversationUserID(message *tgbotapi.Message) (int64, bool) {
+	switch {
+	case isAnonymousAdmin(message):
+		return 0, false
+	case message.SenderChat != nil:
+		return message.SenderChat.ID, true
+	case message.From == nil || message.From.ID == groupAnonymousBotID:
+		return 0, false
+	default:
+		return message.From.ID, true
 	}
+}
 
-	// Извлекаем дату из последней части
-	dateStr := parts[len(parts)-1]
-	birthday, err := time.Parse("02.01.2006", dateStr)
-	if err != nil {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "Неверный формат даты. Используйте: ДД.ММ.ГГГГ")
-		_, err := h.bot.Send(msg)
+// rejectAnonymous сообщает анонимному администратору, что пошаговые действия ему недоступны
+func (h *Handler) rejectAnonymous(ctx context.Context, chatID int64) error {
+	msg := tgbotapi.NewMessage(chatID, h.localizer(ctx, chatID).T("conversation.anonymous"))
+	_, err := h.send(ctx, msg)
+	return err
+}
+
+// handleTimezone показывает или изменяет часовой пояс группы.
+// Без аргументов выводит текущий пояс, с аргументом устанавливает новый (например, /timezone Asia/Yekaterinburg).
+func (h *Handler) handleTimezone(ctx context.Context, message *tgbotapi.Message) error {
+	chatID := message.Chat.ID
+	l := h.localizer(ctx, chatID)
+	name := strings.TrimSpace(message.CommandArguments())
+
+	if name == "" {
+		loc, err := h.store.GetTimezone(ctx, chatID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении часового пояса: %w", err)
+		}
+		msg := tgbotapi.NewMessage(chatID, l.T("timezone.current", loc))
+		_, err = h.send(ctx, msg)
 		return err
 	}
 
-	// Объединяем все части кроме последней как имя
-	name := strings.Join(parts[:len(parts)-1], " ")
+	if ok, err := h.requireEditor(ctx, message); !ok {
+		return err
+	}
 
-	// Создаем запись о дне рождения
-	b := &models.Birthday{
-		Name:     name,
-		Birthday: birthday,
-		GroupID:  message.Chat.ID,
+	// Пустое имя и "Local" time.LoadLocation понимает как UTC и пояс сервера, поэтому отклоняем их
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		msg := tgbotapi.NewMessage(chatID, l.T("timezone.unknown", name))
+		_, err := h.send(ctx, msg)
+		return err
 	}
 
-	// Сохраняем в базу данных
-	if err := h.store.AddBirthday(ctx, b); err != nil {
-		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("Ошибка при добавлении дня рождения: %v", err))
-		_, err := h.bot.Send(msg)
+	if err := h.store.SetTimezone(ctx, chatID, loc); err != nil {
+		msg := tgbotapi.NewMessage(chatID, l.T("timezone.save_error", h.errorText(ctx, l, err)))
+		_, err := h.send(ctx, msg)
 		return err
 	}
 
-	// Отправляем подтверждение
-	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("✅ День рождения %s успешно добавлен!", name))
-	_, err = h.bot.Send(msg)
+	msg := tgbotapi.NewMessage(chatID, l.T("timezone.done", loc))
+	_, err = h.send(ctx, msg)
 	return err
 }
 
-// processDeleteBirthdayByName обрабатывает удаление дня рождения по имени
-func (h *Handler) processDeleteBirthdayByName(ctx context.Context, message *tgbotapi.Message) error {
-	// Получаем список дней рождения
-	birthdays, err := h.store.GetBirthdays(ctx, message.Chat.ID)
-	if err != nil {
-		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Ошибка при получении дней рождения: %v", err))
-		_, err := h.bot.Send(msg)
+// handleReminders показывает или изменяет сроки напоминаний группы.
+// Принимает список дней до дня рождения (например, /reminders 14 7 1 0) или reset для сброса.
+func (h *Handler) handleReminders(ctx context.Context, message *tgbotapi.Message) error {
+	chatID := message.Chat.ID
+	l := h.localizer(ctx, chatID)
+	args := strings.Fields(message.CommandArguments())
+
+	if len(args) == 0 {
+		offsets, err := h.store.GetReminderOffsets(ctx, chatID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении сроков напоминаний: %w", err)
+		}
+		msg := tgbotapi.NewMessage(chatID, l.T("reminders.current", formatReminderOffsets(l, offsets)))
+		_, err = h.send(ctx, msg)
+		return err
+	}
+
+	if ok, err := h.requireEditor(ctx, message); !ok {
 		return err
 	}
 
-	// Ищем день рождения по имени
-	var foundBirthday *models.Birthday
-	for _, b := range birthdays {
-		if strings.EqualFold(b.Name, message.Text) {
-			foundBirthday = b
-			break
+	var offsets []int
+	if !(len(args) == 1 && args[0] == "reset") {
+		for _, arg := range args {
+			days, err := strconv.Atoi(arg)
+			if err != nil {
+				msg := tgbotapi.NewMessage(chatID, l.T("reminders.bad_days", arg))
+				_, err := h.send(ctx, msg)
+				return err
+			}
+			offsets = append(offsets, days)
 		}
 	}
 
-	if foundBirthday == nil {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ День рождения не найден. Проверьте правильность имени и фамилии.")
-		_, err := h.bot.Send(msg)
+	if err := h.store.SetReminderOffsets(ctx, chatID, offsets); err != nil {
+		msg := tgbotapi.NewMessage(chatID, l.T("reminders.save_error", h.errorText(ctx, l, err)))
+		_, err := h.send(ctx, msg)
 		return err
 	}
 
-	// Удаляем день рождения
-	if err := h.store.DeleteBirthday(ctx, message.Chat.ID, foundBirthday.ID); err != nil {
-		msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("❌ Ошибка при удалении дня рождения: %v", err))
-		_, err := h.bot.Send(msg)
+	saved, err := h.store.GetReminderOffsets(ctx, chatID)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении сроков напоминаний: %w", err)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, l.T("reminders.done", formatReminderOffsets(l, saved)))
+	_, err = h.send(ctx, msg)
+	return err
+}
+
+// formatReminderOffsets описывает сроки напоминаний словами
+func formatReminderOffsets(l i18n.Localizer, offsets []int) string {
+	parts := make([]string, 0, len(offsets))
+	for _, days := range offsets {
+		if days == 0 {
+			parts = append(parts, l.T("reminders.on_day"))
+		} else {
+			parts = append(parts, l.T("reminders.before", days, l.Days(days)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// handleEditors показывает и изменяет список редакторов группы.
+// Пользователь указывается ответом на его сообщение или числовым ID: /editors add 123456.
+func (h *Handler) handleEditors(ctx context.Context, message *tgbotapi.Message) error {
+	chatID := message.Chat.ID
+	l := h.localizer(ctx, chatID)
+	args := strings.Fields(message.CommandArguments())
+
+	if len(args) == 0 {
+		editors, err := h.store.GetEditors(ctx, chatID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении редакторов: %w", err)
+		}
+
+		text := l.T("editors.none")
+		if len(editors) > 0 {
+			var list strings.Builder
+			list.WriteString(l.T("editors.header") + "\n")
+			for _, e := range editors {
+				list.WriteString(fmt.Sprintf("- %s (%d)\n", e.Name, e.UserID))
+			}
+			text = list.String()
+		}
+
+		msg := tgbotapi.NewMessage(chatID, text)
+		_, err = h.send(ctx, msg)
 		return err
 	}
 
-	// Отправляем подтверждение
-	msg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("✅ День рождения %s успешно удален!", foundBirthday.Name))
-	_, err = h.bot.Send(msg)
+	// Управлять списком редакторов могут только администраторы
+	admin, err := h.isAdminMessage(ctx, message)
+	if err != nil {
+		return err
+	}
+	if !admin {
+		msg := tgbotapi.NewMessage(chatID, l.T("editors.admins_only"))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	editor := &models.Editor{GroupID: chatID}
+	if reply := message.ReplyToMessage; reply != nil && reply.From != nil {
+		editor.UserID = reply.From.ID
+		editor.Name = userDisplayName(reply.From)
+	} else if len(args) > 1 {
+		editor.UserID, _ = strconv.ParseInt(args[1], 10, 64)
+		editor.Name = args[1]
+	}
+
+	if editor.UserID == 0 || (args[0] != "add" && args[0] != "remove") {
+		msg := tgbotapi.NewMessage(chatID, l.T("editors.usage"))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	var text string
+	if args[0] == "add" {
+		err = h.store.AddEditor(ctx, editor)
+		text = l.T("editors.added", editor.Name)
+	} else {
+		err = h.store.RemoveEditor(ctx, chatID, editor.UserID)
+		text = l.T("editors.removed", editor.Name)
+	}
+	if err != nil {
+		text = l.T("editors.error", h.errorText(ctx, l, err))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	_, err = h.send(ctx, msg)
 	return err
 }
+
+// groupAnonymousBotID ID служебного аккаунта GroupAnonymousBot, от которого приходят сообщения анонимных администраторов
+const groupAnonymousBotID = 1087968824
+
+// isAnonymousAdmin проверяет, отправлено ли сообщение анонимным администратором от имени группы
+func isAnonymousAdmin(message *tgbotapi.Message) bool {
+	return message.SenderChat != nil && message.SenderChat.ID == message.Chat.ID
+}
+
+// isAdminMessage проверяет, является ли автор сообщения администратором чата
+func (h *Handler) isAdminMessage(ctx context.Context, message *tgbotapi.Message) (bool, error) {
+	if isAnonymousAdmin(message) {
+		return true, nil
+	}
+	if message.From == nil {
+		return false, nil
+	}
+	return h.perms.IsAdmin(ctx, message.Chat, message.From.ID)
+}
+
+// requireEditor проверяет право автора сообщения изменять данные группы
+// и сообщает об отказе, если права нет
+func (h *Handler) requireEditor(ctx context.Context, message *tgbotapi.Message) (bool, error) {
+	ok := isAnonymousAdmin(message)
+	if !ok && message.From != nil {
+		var err error
+		ok, err = h.perms.CanEdit(ctx, message.Chat, message.From.ID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if !ok {
+		msg := tgbotapi.NewMessage(message.Chat.ID, h.localizer(ctx, message.Chat.ID).T("access.denied"))
+		_, err := h.send(ctx, msg)
+		return false, err
+	}
+
+	return true, nil
+}
+
+// userDisplayName возвращает имя пользователя для показа в списках
+func userDisplayName(user *tgbotapi.User) string {
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if user.UserName != "" {
+		name += " (@" + user.UserName + ")"
+	}
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,100 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"Eldarius_bot/internal/metrics"
+)
+
+const (
+	// readyTimeout время на проверку базы данных и Bot API в /readyz
+	readyTimeout = 5 * time.Second
+	// httpShutdownTimeout время на завершение запросов к HTTP-серверу при остановке
+	httpShutdownTimeout = 5 * time.Second
+)
+
+// newMux создает маршруты HTTP-сервера: проверки состояния и метрики.
+// Webhook регистрирует свой путь позже, при запуске получения обновлений.
+func (s *Service) newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.Handle("GET /metrics", metrics.Handler())
+	return mux
+}
+
+// handleHealthz отвечает, что процесс жив
+func (s *Service) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz отвечает, готов ли бот работать: доступны база данных и Bot API
+func (s *Service) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+	defer cancel()
+
+	if err := s.ready(ctx); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// ready проверяет соединение с базой данных и вызывает getMe
+func (s *Service) ready(ctx context.Context) error {
+	if err := s.store.Ping(ctx); err != nil {
+		return err
+	}
+
+	// Клиент Bot API не принимает контекст, поэтому ждем ответа не дольше ctx
+	result := make(chan error, 1)
+	go func() {
+		_, err := s.bot.GetMe()
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		if err != nil {
+			return fmt.Errorf("ошибка обращения к Bot API: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("нет ответа от Bot API: %w", ctx.Err())
+	}
+}
+
+// startHTTPServer запускает HTTP-сервер на ListenAddr и возвращает функцию его остановки.
+// Если адрес не задан, сервер не запускается и функция остановки равна nil.
+func (s *Service) startHTTPServer() (func(), error) {
+	if s.config.ListenAddr == "" {
+		return nil, nil
+	}
+
+	listener, err := net.Listen("tcp", s.config.ListenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запуска HTTP-сервера на %s: %w", s.config.ListenAddr, err)
+	}
+
+	server := &http.Server{Handler: s.mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("Ошибка HTTP-сервера", slog.Any("error", err))
+		}
+	}()
+	s.logger.Info("HTTP-сервер запущен", slog.String("addr", listener.Addr().String()))
+
+	stop := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), httpShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			s.logger.Error("Ошибка остановки HTTP-сервера", slog.Any("error", err))
+		}
+	}
+	return stop, nil
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"Eldarius_bot/internal/i18n"
+	"Eldarius_bot/internal/models"
+	"Eldarius_bot/internal/storage"
+	"Eldarius_bot/internal/templates"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// localizer возвращает Localizer на языке группы.
+// Если язык не удалось получить, тексты формируются на языке по умолчанию.
+func (h *Handler) localizer(ctx context.Context, chatID int64) i18n.Localizer {
+	code, err := h.store.GetLanguage(ctx, chatID)
+	if err != nil {
+		h.log(ctx).Warn("Ошибка получения языка группы", slog.Int64("group_id", chatID), slog.Any("error", err))
+		return i18n.New(i18n.Default)
+	}
+	lang, _ := i18n.Parse(code)
+	return i18n.New(lang)
+}
+
+// errorKeys ключи каталога для ошибок, о которых сообщается пользователю
+var errorKeys = []struct {
+	err error
+	key string
+}{
+	{models.ErrEmptyName, "error.empty_name"},
+	{models.ErrEmptyBirthday, "error.empty_birthday"},
+	{models.ErrFutureBirthday, "error.future_birthday"},
+	{models.ErrBirthdayTooOld, "error.birthday_too_old"},
+	{storage.ErrBirthdayNotFound, "error.birthday_notfound"},
+	{storage.ErrBirthdayLimit, "error.birthday_limit"},
+	{storage.ErrTooManyReminders, "error.too_many_reminder"},
+	{storage.ErrReminderRange, "error.reminder_range"},
+	{storage.ErrEditorNotFound, "error.editor_notfound"},
+	{storage.ErrGreetingNotFound, "error.greeting_notfound"},
+	{storage.ErrGreetingLimit, "error.greeting_limit"},
+	{storage.ErrEmptyGreeting, "error.greeting_empty"},
+	{templates.ErrSyntax, "error.template_syntax"},
+	{templates.ErrExecute, "error.template_execute"},
+	{templates.ErrEmpty, "error.template_empty"},
+	{templates.ErrTooLong, "error.template_too_long"},
+	{templates.ErrRange, "error.template_range"},
+}
+
+// errorText описывает ошибку на языке группы. Ошибки без перевода пользователь видит
+// как внутреннюю ошибку, а их исходный текст записывается только в журнал.
+func (h *Handler) errorText(ctx context.Context, l i18n.Localizer, err error) string {
+	for _, e := range errorKeys {
+		if errors.Is(err, e.err) {
+			return l.T(e.key)
+		}
+	}
+	h.log(ctx).Warn("Ошибка без перевода показана пользователю как внутренняя", slog.Any("error", err))
+	return l.T("error.internal")
+}
+
+// handleLanguage показывает или изменяет язык бота в группе.
+// Без аргументов выводит текущий язык, с кодом языка устанавливает новый (например, /language en).
+func (h *Handler) handleLanguage(ctx context.Context, message *tgbotapi.Message) error {
+	chatID := message.Chat.ID
+	l := h.localizer(ctx, chatID)
+	code := strings.TrimSpace(message.CommandArguments())
+
+	if code == "" {
+		msg := tgbotapi.NewMessage(chatID, l.T("language.current", l.T("language.name"), languageList()))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	if ok, err := h.requireEditor(ctx, message); !ok {
+		return err
+	}
+
+	lang, ok := i18n.Parse(code)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, l.T("language.unknown", code, languageList()))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	if err := h.store.SetLanguage(ctx, chatID, string(lang)); err != nil {
+		msg := tgbotapi.NewMessage(chatID, l.T("language.error", h.errorText(ctx, l, err)))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	// Подтверждение уже на новом языке
+	l = i18n.New(lang)
+	msg := tgbotapi.NewMessage(chatID, l.T("language.done", l.T("language.name")))
+	_, err := h.send(ctx, msg)
+	return err
+}
+
+// languageList перечисляет поддерживаемые языки: "ru (русский), en (English)"
+func languageList() string {
+	parts := make([]string, 0, len(i18n.Languages()))
+	for _, lang := range i18n.Languages() {
+		parts = append(parts, fmt.Sprintf("%s (%s)", lang, i18n.New(lang).T("language.name")))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,48 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"Eldarius_bot/internal/metrics"
+	"Eldarius_bot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleMigration переносит дни рождения и настройки группы, которую Telegram
+// превратил в супергруппу с новым идентификатором
+func (h *Handler) handleMigration(ctx context.Context, message *tgbotapi.Message) error {
+	if err := h.store.MigrateGroup(ctx, message.Chat.ID, message.MigrateToChatID); err != nil {
+		return fmt.Errorf("ошибка переноса группы в супергруппу: %w", err)
+	}
+
+	metrics.ForgetGroup(message.Chat.ID)
+	h.log(ctx).Info("Группа стала супергруппой, данные перенесены",
+		slog.Int64("new_group_id", message.MigrateToChatID))
+	return nil
+}
+
+// HandleMyChatMember отслеживает участие бота в чате: если бота удалили из группы
+// или пользователь заблокировал его, чат становится неактивным и планировщик его пропускает.
+// Когда бота возвращают, чат снова становится активным.
+func (h *Handler) HandleMyChatMember(ctx context.Context, update *tgbotapi.ChatMemberUpdated) error {
+	chat := &update.Chat
+
+	if member := update.NewChatMember; member.HasLeft() || member.WasKicked() {
+		if err := h.store.SetGroupActive(ctx, chat.ID, false); err != nil {
+			return err
+		}
+		metrics.ForgetGroup(chat.ID)
+		h.log(ctx).Info("Бот удален из чата", slog.String("status", member.Status))
+		return nil
+	}
+
+	// Бота добавили или вернули: регистрируем группу, даже если в ней еще не писали
+	if err := h.store.EnsureGroup(ctx, &models.Group{ID: chat.ID, Title: chatTitle(chat)}); err != nil {
+		return fmt.Errorf("ошибка регистрации группы: %w", err)
+	}
+	h.log(ctx).Info("Бот добавлен в чат", slog.String("status", update.NewChatMember.Status))
+	return nil
+}
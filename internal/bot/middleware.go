@@ -1 +1,272 @@
 package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/logging"
+	"Eldarius_bot/internal/metrics"
+	"Eldarius_bot/internal/models"
+	"Eldarius_bot/internal/storage"
+	"Eldarius_bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrRateLimited возвращается, когда пользователь превысил допустимую частоту запросов
+var ErrRateLimited = errors.New("превышена частота запросов")
+
+// UpdateHandler обрабатывает одно обновление от Telegram
+type UpdateHandler func(ctx context.Context, update *tgbotapi.Update) error
+
+// Middleware оборачивает обработчик обновлений дополнительным поведением
+type Middleware func(next UpdateHandler) UpdateHandler
+
+// Chain собирает цепочку middleware вокруг обработчика.
+// Первая middleware в списке получает обновление первой.
+func Chain(handler UpdateHandler, middlewares ...Middleware) UpdateHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// updateChat возвращает чат, в котором произошло обновление, или nil
+func updateChat(update *tgbotapi.Update) *tgbotapi.Chat {
+	if update.CallbackQuery != nil && update.CallbackQuery.Message == nil {
+		// Нажатие на кнопку inline-сообщения не привязано к чату
+		return nil
+	}
+	if update.MyChatMember != nil {
+		return &update.MyChatMember.Chat
+	}
+	return update.FromChat()
+}
+
+// updateKind возвращает тип обновления для журнала
+func updateKind(update *tgbotapi.Update) string {
+	switch {
+	case update.Message != nil && update.Message.IsCommand():
+		return "command:" + update.Message.Command()
+	case update.Message != nil:
+		return "message"
+	case update.CallbackQuery != nil:
+		return "callback"
+	case update.MyChatMember != nil:
+		return "member"
+	default:
+		return "other"
+	}
+}
+
+// Recover перехватывает панику в обработчике и превращает ее в ошибку,
+// чтобы одно обновление не останавливало весь бот
+func Recover() Middleware {
+	return func(next UpdateHandler) UpdateHandler {
+		return func(ctx context.Context, update *tgbotapi.Update) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("паника при обработке обновления %d: %v\n%s", update.UpdateID, r, debug.Stack())
+				}
+			}()
+			return next(ctx, update)
+		}
+	}
+}
+
+// Timeout ограничивает время обработки одного обновления
+func Timeout(d time.Duration) Middleware {
+	return func(next UpdateHandler) UpdateHandler {
+		return func(ctx context.Context, update *tgbotapi.Update) error {
+			ctx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+			return next(ctx, update)
+		}
+	}
+}
+
+// Logging присваивает обновлению correlation_id и передает дальше через контекст журнал
+// с этим идентификатором, ID группы и пользователя и командой. По окончании обработки
+// записывает ее длительность и ошибку с кодом Telegram, если она возникла.
+func Logging(logger *slog.Logger) Middleware {
+	return func(next UpdateHandler) UpdateHandler {
+		return func(ctx context.Context, update *tgbotapi.Update) error {
+			start := time.Now()
+
+			kind, command, _ := strings.Cut(updateKind(update), ":")
+			attrs := []any{
+				slog.String("correlation_id", logging.NewCorrelationID()),
+				slog.Int("update_id", update.UpdateID),
+				slog.String("kind", kind),
+			}
+			if chat := updateChat(update); chat != nil {
+				attrs = append(attrs, slog.Int64("group_id", chat.ID))
+			}
+			if user := update.SentFrom(); user != nil {
+				attrs = append(attrs, slog.Int64("user_id", user.ID))
+			}
+			if command != "" {
+				attrs = append(attrs, slog.String("command", command))
+			}
+			log := logger.With(attrs...)
+
+			err := next(logging.WithLogger(ctx, log), update)
+
+			duration := slog.Duration("duration", time.Since(start))
+			if err != nil {
+				log.Error("Ошибка обработки обновления", append([]any{duration}, telegram.ErrorAttrs(err)...)...)
+			} else {
+				log.Info("Обработано обновление", duration)
+			}
+
+			return err
+		}
+	}
+}
+
+// knownCommands команды, которые понимает HandleMessage. Остальные команды учитываются
+// в метриках как "unknown", чтобы произвольный текст после косой черты не плодил ряды.
+var knownCommands = map[string]bool{
+	"start": true, "help": true, "remind": true, "list": true, "add": true, "edit": true,
+	"delete": true, "cancel": true, "timezone": true, "reminders": true, "editors": true,
+	"template": true, "greetings": true, "language": true,
+}
+
+// Metrics считает обработанные обновления по типу и результату и полученные команды
+func Metrics() Middleware {
+	return func(next UpdateHandler) UpdateHandler {
+		return func(ctx context.Context, update *tgbotapi.Update) error {
+			err := next(ctx, update)
+
+			kind, _, _ := strings.Cut(updateKind(update), ":")
+			status := "ok"
+			if err != nil {
+				status = "error"
+			}
+			metrics.UpdatesHandled.WithLabelValues(kind, status).Inc()
+
+			if update.Message != nil && update.Message.IsCommand() {
+				command := update.Message.Command()
+				if !knownCommands[command] {
+					command = "unknown"
+				}
+				metrics.Commands.WithLabelValues(command).Inc()
+			}
+
+			return err
+		}
+	}
+}
+
+// RegisterGroup регистрирует чат в хранилище при первом сообщении,
+// чтобы планировщик знал о группе еще до добавления дней рождения.
+// Служебные сообщения о переходе в супергруппу не регистрируют чат: старую группу
+// переносит и удаляет обработчик, а новая создается при переносе.
+func RegisterGroup(store storage.Repository) Middleware {
+	return func(next UpdateHandler) UpdateHandler {
+		return func(ctx context.Context, update *tgbotapi.Update) error {
+			if message := update.Message; message != nil && message.Chat != nil &&
+				message.MigrateToChatID == 0 && message.MigrateFromChatID == 0 {
+				chat := message.Chat
+				if err := store.EnsureGroup(ctx, &models.Group{ID: chat.ID, Title: chatTitle(chat)}); err != nil {
+					return fmt.Errorf("ошибка регистрации группы: %w", err)
+				}
+			}
+			return next(ctx, update)
+		}
+	}
+}
+
+// chatTitle возвращает название чата для хранилища
+func chatTitle(chat *tgbotapi.Chat) string {
+	if chat.Title != "" {
+		return chat.Title
+	}
+	// У личных чатов нет названия, используем имя пользователя
+	return strings.TrimSpace(chat.FirstName + " " + chat.LastName)
+}
+
+// RateLimit ограничивает частоту обновлений от одного пользователя:
+// не более limit обновлений за interval с равномерным восполнением.
+// Лишние обновления отбрасываются с ошибкой ErrRateLimited. Время берется из часов clk.
+func RateLimit(limit int, interval time.Duration, clk clock.Clock) Middleware {
+	limiter := newRateLimiter(limit, interval)
+	return func(next UpdateHandler) UpdateHandler {
+		return func(ctx context.Context, update *tgbotapi.Update) error {
+			if user := update.SentFrom(); user != nil && !limiter.allow(user.ID, clk.Now()) {
+				return ErrRateLimited
+			}
+			return next(ctx, update)
+		}
+	}
+}
+
+// rateLimiter реализует алгоритм token bucket для каждого пользователя
+type rateLimiter struct {
+	mu          sync.Mutex
+	capacity    float64
+	rate        float64 // Токенов в секунду
+	buckets     map[int64]*bucket
+	lastCleanup time.Time
+	interval    time.Duration
+}
+
+// bucket хранит запас запросов одного пользователя
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter создает ограничитель частоты запросов
+func newRateLimiter(limit int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		capacity: float64(limit),
+		rate:     float64(limit) / interval.Seconds(),
+		buckets:  make(map[int64]*bucket),
+		interval: interval,
+	}
+}
+
+// allow расходует один запрос пользователя, если запас еще есть
+func (l *rateLimiter) allow(userID int64, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cleanup(now)
+
+	b, ok := l.buckets[userID]
+	if !ok {
+		b = &bucket{tokens: l.capacity, last: now}
+		l.buckets[userID] = b
+	}
+
+	b.tokens = min(l.capacity, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// cleanup удаляет пользователей, чей запас уже полностью восстановился
+func (l *rateLimiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < l.interval {
+		return
+	}
+	l.lastCleanup = now
+
+	for id, b := range l.buckets {
+		if now.Sub(b.last) >= l.interval {
+			delete(l.buckets, id)
+		}
+	}
+}
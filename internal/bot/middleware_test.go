@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/logging"
+	"Eldarius_bot/internal/storage"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestLoggingCorrelation(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, slog.LevelInfo, logging.FormatJSON)
+	if err != nil {
+		t.Fatalf("ошибка создания журнала: %v", err)
+	}
+
+	// Обработчик пишет в журнал из контекста, как это делают Handler и хранилище
+	handler := Chain(func(ctx context.Context, update *tgbotapi.Update) error {
+		logging.FromContext(ctx, logging.Discard()).Warn("Запись обработчика")
+		return errors.New("сбой")
+	}, Logging(logger))
+
+	update := &tgbotapi.Update{
+		UpdateID: 7,
+		Message: &tgbotapi.Message{
+			Chat:     &tgbotapi.Chat{ID: -100, Type: "group"},
+			From:     &tgbotapi.User{ID: 42},
+			Text:     "/list",
+			Entities: []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 5}},
+		},
+	}
+	if err := handler(context.Background(), update); err == nil {
+		t.Fatal("ошибка обработчика потеряна")
+	}
+
+	type entry struct {
+		Level         string `json:"level"`
+		CorrelationID string `json:"correlation_id"`
+		UpdateID      int    `json:"update_id"`
+		GroupID       int64  `json:"group_id"`
+		UserID        int64  `json:"user_id"`
+		Command       string `json:"command"`
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("записей журнала %d, ожидалось 2:\n%s", len(lines), buf.String())
+	}
+	var entries []entry
+	for _, line := range lines {
+		var e entry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("запись журнала %q не разобрана: %v", line, err)
+		}
+		if e.CorrelationID == "" || e.UpdateID != 7 || e.GroupID != -100 || e.UserID != 42 || e.Command != "list" {
+			t.Fatalf("в записи журнала нет атрибутов обновления: %s", line)
+		}
+		entries = append(entries, e)
+	}
+	if entries[0].CorrelationID != entries[1].CorrelationID {
+		t.Fatalf("разные correlation_id у записей одного обновления: %q и %q", entries[0].CorrelationID, entries[1].CorrelationID)
+	}
+	if entries[1].Level != "ERROR" {
+		t.Fatalf("итоговая запись с уровнем %s, ожидался ERROR", entries[1].Level)
+	}
+}
+
+func TestRegisterGroupSkipsMigrationMessages(t *testing.T) {
+	store, err := storage.NewSQLite(filepath.Join(t.TempDir(), "birthdays.db"), clock.Real{}, logging.Discard())
+	if err != nil {
+		t.Fatalf("ошибка открытия хранилища: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	handler := Chain(func(ctx context.Context, update *tgbotapi.Update) error { return nil }, RegisterGroup(store))
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		message *tgbotapi.Message
+		want    bool
+	}{
+		{
+			name:    "обычное сообщение",
+			message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: -100, Type: "group", Title: "Семья"}, Text: "привет"},
+			want:    true,
+		},
+		{
+			name:    "группа стала супергруппой",
+			message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: -200, Type: "group", Title: "Семья"}, MigrateToChatID: -1000200},
+		},
+		{
+			name:    "супергруппа создана из группы",
+			message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: -1000300, Type: "supergroup", Title: "Семья"}, MigrateFromChatID: -300},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := handler(ctx, &tgbotapi.Update{Message: tt.message}); err != nil {
+				t.Fatalf("ошибка обработки: %v", err)
+			}
+			_, err := store.GetGroup(ctx, tt.message.Chat.ID)
+			if registered := err == nil; registered != tt.want {
+				t.Errorf("группа зарегистрирована = %v, ожидалось %v", registered, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	clk := clock.NewFake(time.Date(2026, time.May, 10, 9, 0, 0, 0, time.UTC))
+	handler := Chain(func(ctx context.Context, update *tgbotapi.Update) error { return nil },
+		RateLimit(2, 10*time.Second, clk))
+
+	// update возвращает сообщение пользователя userID
+	update := func(userID int64) *tgbotapi.Update {
+		return &tgbotapi.Update{Message: &tgbotapi.Message{
+			Chat: &tgbotapi.Chat{ID: -100, Type: "group"},
+			From: &tgbotapi.User{ID: userID},
+			Text: "привет",
+		}}
+	}
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		userID  int64
+		wantErr error
+	}{
+		{name: "первое обновление", userID: 1},
+		{name: "второе обновление", userID: 1},
+		{name: "запас исчерпан", userID: 1, wantErr: ErrRateLimited},
+		{name: "у другого пользователя свой запас", userID: 2},
+		{name: "запас еще не восстановился", advance: 4 * time.Second, userID: 1, wantErr: ErrRateLimited},
+		{name: "восстановился один запрос", advance: time.Second, userID: 1},
+		{name: "и снова исчерпан", userID: 1, wantErr: ErrRateLimited},
+	}
+	for _, step := range steps {
+		clk.Advance(step.advance)
+		if err := handler(context.Background(), update(step.userID)); !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: ошибка %v, ожидалась %v", step.name, err, step.wantErr)
+		}
+	}
+}
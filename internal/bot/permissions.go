@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/storage"
+	"Eldarius_bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// adminCacheTTL время, в течение которого список администраторов группы берется из кэша
+const adminCacheTTL = 5 * time.Minute
+
+// Permissions проверяет, может ли пользователь изменять дни рождения и настройки группы.
+// Право есть у администраторов чата и у редакторов, добавленных командой /editors.
+type Permissions struct {
+	bot   telegram.Messenger
+	store storage.Repository
+	clock clock.Clock
+	ttl   time.Duration
+
+	mu     sync.Mutex
+	admins map[int64]adminCacheEntry
+}
+
+// adminCacheEntry кэшированный список администраторов группы
+type adminCacheEntry struct {
+	ids     map[int64]bool
+	expires time.Time
+}
+
+// NewPermissions создает проверку прав с кэшем администраторов
+func NewPermissions(store storage.Repository, bot telegram.Messenger, clk clock.Clock) *Permissions {
+	return &Permissions{
+		bot:    bot,
+		store:  store,
+		clock:  clk,
+		ttl:    adminCacheTTL,
+		admins: make(map[int64]adminCacheEntry),
+	}
+}
+
+// IsAdmin проверяет, является ли пользователь администратором чата.
+// В личной переписке с ботом пользователь считается администратором.
+func (p *Permissions) IsAdmin(ctx context.Context, chat *tgbotapi.Chat, userID int64) (bool, error) {
+	if chat.IsPrivate() {
+		return true, nil
+	}
+
+	admins, err := p.chatAdmins(chat.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return admins[userID], nil
+}
+
+// CanEdit проверяет, может ли пользователь изменять данные группы
+func (p *Permissions) CanEdit(ctx context.Context, chat *tgbotapi.Chat, userID int64) (bool, error) {
+	admin, err := p.IsAdmin(ctx, chat, userID)
+	if err != nil || admin {
+		return admin, err
+	}
+
+	return p.store.IsEditor(ctx, chat.ID, userID)
+}
+
+// Invalidate сбрасывает кэш администраторов группы
+func (p *Permissions) Invalidate(chatID int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.admins, chatID)
+}
+
+// chatAdmins возвращает ID администраторов чата, обращаясь к Telegram не чаще раза в ttl
+func (p *Permissions) chatAdmins(chatID int64) (map[int64]bool, error) {
+	p.mu.Lock()
+	entry, ok := p.admins[chatID]
+	p.mu.Unlock()
+	if ok && p.clock.Now().Before(entry.expires) {
+		return entry.ids, nil
+	}
+
+	members, err := p.bot.GetChatAdministrators(tgbotapi.ChatAdministratorsConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения администраторов чата: %w", err)
+	}
+
+	ids := make(map[int64]bool, len(members))
+	for _, m := range members {
+		if m.User != nil {
+			ids[m.User.ID] = true
+		}
+	}
+
+	p.mu.Lock()
+	p.admins[chatID] = adminCacheEntry{ids: ids, expires: p.clock.Now().Add(p.ttl)}
+	p.mu.Unlock()
+
+	return ids, nil
+}
@@ -2,103 +2,211 @@ package bot
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
-	"os/signal"
-	"syscall"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
 
+	"Eldarius_bot/internal/clock"
 	"Eldarius_bot/internal/config"
 	"Eldarius_bot/internal/scheduler"
 	"Eldarius_bot/internal/storage"
+	"Eldarius_bot/internal/telegram"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+const (
+	// updateTimeout время на обработку одного обновления
+	updateTimeout = 30 * time.Second
+	// userRateLimit и userRateInterval ограничивают частоту запросов одного пользователя
+	userRateLimit    = 20
+	userRateInterval = time.Minute
+	// updateWorkers сколько чатов обрабатываются одновременно, updateQueueSize очередь каждого обработчика
+	updateWorkers   = 8
+	updateQueueSize = 64
+)
+
+// sendLimits ограничения частоты отправки сообщений. Тесты ослабляют их, чтобы не ждать очереди.
+var sendLimits = telegram.DefaultLimits
+
 // Service представляет сервис бота
 type Service struct {
-	bot       *tgbotapi.BotAPI
+	bot       telegram.Client
+	sender    *telegram.Sender
 	handler   *Handler
+	handle    UpdateHandler
 	store     storage.Repository
 	scheduler *scheduler.Scheduler
 	config    *config.Config
+	mux       *http.ServeMux // Маршруты HTTP-сервера: проверки состояния, метрики и webhook
+	logger    *slog.Logger
+
+	stopReceiving func()             // Останавливает получение обновлений и HTTP-сервер
+	stopScheduler context.CancelFunc // Отменяет контекст планировщика
+	draining      chan struct{}      // Закрывается при остановке получения обновлений
+	processed     chan struct{}      // Закрывается, когда обработка обновлений завершена
+	wg            sync.WaitGroup     // Обработка обновлений и планировщик
 }
 
-// NewService создает новый сервис
-func NewService(cfg *config.Config, store storage.Repository) (*Service, error) {
+// NewService создает новый сервис. Часы clk и журнал logger передаются обработчику и планировщику.
+func NewService(cfg *config.Config, store storage.Repository, clk clock.Clock, logger *slog.Logger) (*Service, error) {
 	// Создаем экземпляр бота
-	bot, err := tgbotapi.NewBotAPI(cfg.Token)
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(cfg.Token, cfg.APIEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка создания бота: %w", err)
 	}
+	api.Debug = cfg.Debug
+
+	// Обработчик и планировщик отправляют через общую очередь с ограничениями частоты
+	// и повторами. Каждая неудачная попытка записывается в журнал с кодом Telegram.
+	sender := telegram.NewSender(telegram.WithLogging(api, logger), sendLimits, clk, logger)
 
 	// Создаем обработчик
-	handler := NewHandler(store, bot)
+	handler := NewHandler(store, sender, api.Self.UserName, clk, logger)
 
 	// Создаем планировщик
-	scheduler := scheduler.NewScheduler(store, bot)
-
-	return &Service{
+	scheduler := scheduler.NewScheduler(store, sender, clk, logger)
+
+	// Собираем цепочку обработки обновлений
+	handle := Chain(handler.HandleUpdate,
+		Logging(logger),
+		Metrics(),
+		Recover(),
+		RateLimit(userRateLimit, userRateInterval, clk),
+		Timeout(updateTimeout),
+		RegisterGroup(store),
+	)
+
+	s := &Service{
 		config:    cfg,
 		store:     store,
-		bot:       bot,
+		bot:       sender,
+		sender:    sender,
 		handler:   handler,
+		handle:    handle,
 		scheduler: scheduler,
-	}, nil
+		logger:    logger,
+		draining:  make(chan struct{}),
+		processed: make(chan struct{}),
+	}
+	s.mux = s.newMux()
+	return s, nil
+}
+
+// Start запускает получение обновлений и планировщик и работает, пока не отменен ctx
+// или не закрылся канал обновлений. Остановку и ожидание начатой работы выполняет Stop.
+func (s *Service) Start(ctx context.Context) error {
+	if err := s.start(ctx); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-s.processed:
+	}
+	return nil
 }
 
-// Start запускает сервис
-func (s *Service) Start() error {
-	// Настраиваем бота
-	s.bot.Debug = s.config.Debug
+// start запускает HTTP-сервер, получение и обработку обновлений и планировщик, не дожидаясь их завершения
+func (s *Service) start(ctx context.Context) error {
+	// Запускаем HTTP-сервер и получение обновлений через webhook или getUpdates
+	updates, stop, err := s.startReceiving()
+	if err != nil {
+		return err
+	}
+	s.stopReceiving = stop
 
-	// Создаем контекст с возможностью отмены
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	// Отмена ctx не прерывает начатую работу: обработчики и отправки завершаются в Stop
+	work := context.WithoutCancel(ctx)
 
-	// Запускаем планировщик
+	s.wg.Add(1)
 	go func() {
-		if err := s.scheduler.Start(ctx); err != nil {
-			fmt.Printf("Ошибка планировщика: %v\n", err)
-		}
+		defer s.wg.Done()
+		defer close(s.processed)
+		s.processUpdates(work, updates)
 	}()
 
-	// Настраиваем получение обновлений
-	updateConfig := tgbotapi.NewUpdate(0)
-	updateConfig.Timeout = 60
+	schedulerCtx, cancel := context.WithCancel(work)
+	s.stopScheduler = cancel
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.scheduler.Start(schedulerCtx); err != nil && !errors.Is(err, context.Canceled) {
+			s.logger.Error("Ошибка планировщика", slog.Any("error", err))
+		}
+	}()
 
-	updates := s.bot.GetUpdatesChan(updateConfig)
+	return nil
+}
 
-	// Обрабатываем сигналы завершения
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+// processUpdates распределяет обновления между обработчиками, пока не закроется канал:
+// разные чаты обрабатываются параллельно, сообщения одного чата — по порядку. После остановки
+// получения дообрабатывает уже полученные обновления: Telegram считает их доставленными
+// и повторно не пришлет.
+func (s *Service) processUpdates(ctx context.Context, updates tgbotapi.UpdatesChannel) {
+	d := newDispatcher(s.handle, updateWorkers, updateQueueSize)
+	d.start(ctx)
+	defer d.close()
 
-	// Основной цикл обработки
 	for {
 		select {
-		case <-ctx.Done():
-			return nil
-		case <-sigChan:
-			return nil
-		case update := <-updates:
-			if update.Message != nil {
-				if err := s.handler.HandleMessage(update.Message); err != nil {
-					fmt.Printf("Ошибка обработки сообщения: %v\n", err)
-				}
-			} else if update.CallbackQuery != nil {
-				if err := s.handler.HandleCallback(update.CallbackQuery); err != nil {
-					fmt.Printf("Ошибка обработки callback: %v\n", err)
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			d.dispatch(update)
+		case <-s.draining:
+			// Канал getUpdates закрывается только после текущего долгого запроса,
+			// поэтому ждем лишь те обновления, что уже стоят в очереди
+			for {
+				select {
+				case update, ok := <-updates:
+					if !ok {
+						return
+					}
+					d.dispatch(update)
+				default:
+					return
 				}
 			}
 		}
 	}
 }
 
-// Stop останавливает сервис
-func (s *Service) Stop() error {
+// Stop останавливает сервис: прекращает получение обновлений и работу планировщика, ждет
+// завершения начатых обработчиков и отправок не дольше ctx и последним закрывает хранилище.
+func (s *Service) Stop(ctx context.Context) error {
+	if s.stopReceiving != nil {
+		s.stopReceiving()
+		close(s.draining)
+	}
+	if s.stopScheduler != nil {
+		s.stopScheduler()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(finished)
+	}()
+
+	var err error
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		err = fmt.Errorf("начатая работа не завершилась до остановки: %w", ctx.Err())
+	}
+
+	// Прерываем отправки, которые еще ждут очереди или повтора
+	s.sender.Close()
+
 	// Закрываем соединение с базой данных
-	if err := s.store.Close(); err != nil {
-		return fmt.Errorf("ошибка закрытия хранилища: %w", err)
+	if closeErr := s.store.Close(); closeErr != nil {
+		return errors.Join(err, fmt.Errorf("ошибка закрытия хранилища: %w", closeErr))
 	}
 
-	return nil
+	return err
 }
@@ -0,0 +1,922 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/config"
+	"Eldarius_bot/internal/i18n"
+	"Eldarius_bot/internal/logging"
+	"Eldarius_bot/internal/models"
+	"Eldarius_bot/internal/storage"
+	"Eldarius_bot/internal/telegram"
+	"Eldarius_bot/internal/telegram/telegramtest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ru тексты, которые бот отправляет группам с языком по умолчанию
+var ru = i18n.New(i18n.Russian)
+
+func TestMain(m *testing.M) {
+	// Ограничения Telegram проверяются в пакете telegram, здесь они только замедлили бы тесты
+	unlimited := telegram.Rate{Every: time.Millisecond, Burst: 1000}
+	sendLimits = telegram.Limits{Global: unlimited, Private: unlimited, Group: unlimited}
+	os.Exit(m.Run())
+}
+
+// startTestService запускает сервис с поддельным Bot API и временной базой.
+// Обновления получаются через getUpdates, как в рабочем режиме по умолчанию.
+func startTestService(t *testing.T) *telegramtest.Server {
+	t.Helper()
+
+	srv := telegramtest.NewServer()
+	runTestService(t, srv, &config.Config{
+		Token:       telegramtest.Token,
+		APIEndpoint: srv.Endpoint(),
+	})
+	return srv
+}
+
+// newTestService создает сервис с конфигурацией cfg против поддельного Bot API srv и временной базой
+func newTestService(t *testing.T, srv *telegramtest.Server, cfg *config.Config) *Service {
+	t.Helper()
+
+	store, err := storage.NewSQLite(filepath.Join(t.TempDir(), "birthdays.db"), clock.Real{}, logging.Discard())
+	if err != nil {
+		srv.Close()
+		t.Fatalf("ошибка открытия хранилища: %v", err)
+	}
+
+	s, err := NewService(cfg, store, clock.Real{}, logging.Discard())
+	if err != nil {
+		srv.Close()
+		store.Close()
+		t.Fatalf("ошибка создания сервиса: %v", err)
+	}
+
+	return s
+}
+
+// runTestService запускает сервис с конфигурацией cfg против поддельного Bot API srv
+// и останавливает их по окончании теста
+func runTestService(t *testing.T, srv *telegramtest.Server, cfg *config.Config) *Service {
+	t.Helper()
+
+	s := newTestService(t, srv, cfg)
+	if err := s.start(context.Background()); err != nil {
+		srv.Close()
+		s.store.Close()
+		t.Fatalf("ошибка запуска сервиса: %v", err)
+	}
+
+	t.Cleanup(func() {
+		// Stop дожидается начатых обработчиков и закрывает хранилище
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := s.Stop(ctx); err != nil {
+			t.Errorf("ошибка остановки сервиса: %v", err)
+		}
+		srv.Close()
+	})
+
+	return s
+}
+
+// freeAddr возвращает свободный локальный адрес для HTTP-сервера бота
+func freeAddr(t *testing.T) string {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ошибка выбора порта: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().String()
+}
+
+// privateChat возвращает личный чат пользователя с ботом
+func privateChat(user *tgbotapi.User) *tgbotapi.Chat {
+	return &tgbotapi.Chat{ID: user.ID, Type: "private", FirstName: user.FirstName}
+}
+
+// findButton ищет под сообщением кнопку с текстом, содержащим text
+func findButton(t *testing.T, message tgbotapi.Message, text string) string {
+	t.Helper()
+
+	if message.ReplyMarkup != nil {
+		for _, row := range message.ReplyMarkup.InlineKeyboard {
+			for _, button := range row {
+				if strings.Contains(button.Text, text) && button.CallbackData != nil {
+					return *button.CallbackData
+				}
+			}
+		}
+	}
+	t.Fatalf("нет кнопки %q под сообщением %q", text, message.Text)
+	return ""
+}
+
+// lastText возвращает текст последнего сообщения из списка
+func lastText(messages []tgbotapi.Message) string {
+	return messages[len(messages)-1].Text
+}
+
+func TestAddListDeleteFlow(t *testing.T) {
+	srv := startTestService(t)
+	user := &tgbotapi.User{ID: 1001, FirstName: "Мария"}
+	chat := privateChat(user)
+
+	// Добавление одной строкой в ответ на вопрос
+	srv.SendText(chat, user, "/add")
+	messages := srv.WaitMessages(t, chat.ID, 1)
+	if !strings.HasPrefix(lastText(messages), ru.T("add.prompt")) {
+		t.Fatalf("неожиданный вопрос: %q", lastText(messages))
+	}
+
+	srv.SendText(chat, user, "Иван Петров 15.03.1990")
+	messages = srv.WaitMessages(t, chat.ID, 2)
+	if want := ru.T("add.done", "Иван Петров"); lastText(messages) != want {
+		t.Fatalf("ответ на добавление %q, ожидалось %q", lastText(messages), want)
+	}
+
+	// Пошаговое добавление без года рождения
+	srv.SendText(chat, user, "/add")
+	srv.WaitMessages(t, chat.ID, 3)
+	srv.SendText(chat, user, "Анна")
+	messages = srv.WaitMessages(t, chat.ID, 4)
+	if !strings.HasPrefix(lastText(messages), ru.T("add.date_prompt", "Анна", ru.T("date.hint"))) {
+		t.Fatalf("неожиданный вопрос о дате: %q", lastText(messages))
+	}
+	srv.SendText(chat, user, "01.02")
+	messages = srv.WaitMessages(t, chat.ID, 5)
+	if want := ru.T("add.done", "Анна"); lastText(messages) != want {
+		t.Fatalf("ответ на добавление %q, ожидалось %q", lastText(messages), want)
+	}
+
+	// Список содержит обе записи
+	srv.SendText(chat, user, "/list")
+	messages = srv.WaitMessages(t, chat.ID, 6)
+	list := lastText(messages)
+	for _, want := range []string{ru.T("list.header"), "Иван Петров", "15.03.1990", "Анна", "(01.02)"} {
+		if !strings.Contains(list, want) {
+			t.Errorf("в списке нет %q:\n%s", want, list)
+		}
+	}
+
+	// Удаление кнопкой обновляет клавиатуру в том же сообщении
+	srv.SendText(chat, user, "/delete")
+	messages = srv.WaitMessages(t, chat.ID, 7)
+	keyboard := messages[6]
+	if keyboard.Text != ru.T("delete.keyboard") {
+		t.Fatalf("неожиданная клавиатура удаления: %q", keyboard.Text)
+	}
+
+	id := srv.PressButton(user, &keyboard, findButton(t, keyboard, "Иван Петров"))
+	if answer := srv.WaitCallbackAnswer(t, id); answer.ShowAlert {
+		t.Fatalf("нажатие отклонено: %q", answer.Text)
+	}
+	messages = srv.WaitMessages(t, chat.ID, 8)
+	if want := ru.T("delete.done", "Иван Петров"); lastText(messages) != want {
+		t.Fatalf("ответ на удаление %q, ожидалось %q", lastText(messages), want)
+	}
+
+	// Обновления обрабатываются по очереди, поэтому к ответу на /list клавиатура уже изменена
+	srv.SendText(chat, user, "/list")
+	messages = srv.WaitMessages(t, chat.ID, 9)
+	if list := lastText(messages); strings.Contains(list, "Иван Петров") || !strings.Contains(list, "Анна") {
+		t.Fatalf("список после удаления:\n%s", list)
+	}
+	if edited := messages[6]; edited.EditDate == 0 || strings.Contains(edited.ReplyMarkup.InlineKeyboard[0][0].Text, "Иван Петров") {
+		t.Errorf("клавиатура не обновилась после удаления")
+	}
+}
+
+func TestDeleteByNameConversation(t *testing.T) {
+	srv := startTestService(t)
+	user := &tgbotapi.User{ID: 1002, FirstName: "Олег"}
+	chat := privateChat(user)
+
+	srv.SendText(chat, user, "/add")
+	srv.SendText(chat, user, "Петр Сидоров 10.10.1985")
+	srv.WaitMessages(t, chat.ID, 2)
+
+	srv.SendText(chat, user, "/delete")
+	messages := srv.WaitMessages(t, chat.ID, 3)
+	srv.PressButton(user, &messages[2], findButton(t, messages[2], ru.T("delete.manual")))
+	srv.WaitMessages(t, chat.ID, 4)
+
+	srv.SendText(chat, user, "петр сидоров")
+	messages = srv.WaitMessages(t, chat.ID, 5)
+	if !strings.HasPrefix(lastText(messages), ru.T("delete.confirm", "Петр Сидоров", "10.10.1985")) {
+		t.Fatalf("неожиданный запрос подтверждения: %q", lastText(messages))
+	}
+
+	srv.SendText(chat, user, "да")
+	messages = srv.WaitMessages(t, chat.ID, 6)
+	if want := ru.T("delete.done", "Петр Сидоров"); lastText(messages) != want {
+		t.Fatalf("ответ на удаление %q, ожидалось %q", lastText(messages), want)
+	}
+
+	srv.SendText(chat, user, "/list")
+	messages = srv.WaitMessages(t, chat.ID, 7)
+	if want := ru.T("list.empty"); lastText(messages) != want {
+		t.Fatalf("список после удаления %q, ожидалось %q", lastText(messages), want)
+	}
+}
+
+func TestEditFlow(t *testing.T) {
+	srv := startTestService(t)
+	user := &tgbotapi.User{ID: 1004, FirstName: "Ирина"}
+	chat := privateChat(user)
+
+	// reply ждет следующее сообщение бота в чате и возвращает его
+	count := 0
+	reply := func() tgbotapi.Message {
+		t.Helper()
+		count++
+		return srv.WaitMessages(t, chat.ID, count)[count-1]
+	}
+	// press нажимает кнопку с текстом text под сообщением message
+	press := func(message tgbotapi.Message, text string) {
+		t.Helper()
+		id := srv.PressButton(user, &message, findButton(t, message, text))
+		if answer := srv.WaitCallbackAnswer(t, id); answer.ShowAlert {
+			t.Fatalf("нажатие %q отклонено: %q", text, answer.Text)
+		}
+	}
+
+	srv.SendText(chat, user, "/add")
+	reply()
+	srv.SendText(chat, user, "Иван Петров 15.03.1990")
+	reply()
+	srv.SendText(chat, user, "/add")
+	reply()
+	srv.SendText(chat, user, "Анна 01.02")
+	reply()
+
+	// Изменение имени: выбор записи, выбор поля, ввод, подтверждение кнопкой
+	srv.SendText(chat, user, "/edit")
+	keyboard := reply()
+	if keyboard.Text != ru.T("edit.keyboard") {
+		t.Fatalf("неожиданная клавиатура изменения: %q", keyboard.Text)
+	}
+	press(keyboard, "Иван Петров")
+	choice := reply()
+	if want := ru.T("edit.choose_field", "Иван Петров", "15.03.1990"); choice.Text != want {
+		t.Fatalf("выбор поля %q, ожидалось %q", choice.Text, want)
+	}
+	press(choice, ru.T("edit.field_name"))
+	if prompt := reply(); !strings.HasPrefix(prompt.Text, ru.T("edit.name_prompt", "Иван Петров")) {
+		t.Fatalf("неожиданный вопрос об имени: %q", prompt.Text)
+	}
+	srv.SendText(chat, user, "Иван Сидоров")
+	confirm := reply()
+	if want := ru.T("edit.confirm", "Иван Петров (15.03.1990)", "Иван Сидоров", "15.03.1990"); confirm.Text != want {
+		t.Fatalf("запрос подтверждения %q, ожидалось %q", confirm.Text, want)
+	}
+	press(confirm, ru.T("button.save"))
+	if done := reply(); done.Text != ru.T("edit.done", "Иван Сидоров", "15.03.1990") {
+		t.Fatalf("ответ на сохранение %q", done.Text)
+	}
+
+	// Изменение даты: неверный формат и дата в будущем отклоняются, затем изменение отменяется
+	srv.SendText(chat, user, "/edit")
+	press(reply(), "Анна")
+	press(reply(), ru.T("edit.field_date"))
+	if prompt := reply(); !strings.HasPrefix(prompt.Text, ru.T("edit.date_prompt", "Анна", "01.02", ru.T("date.hint"))) {
+		t.Fatalf("неожиданный вопрос о дате: %q", prompt.Text)
+	}
+	srv.SendText(chat, user, "31.02.2000")
+	if retry := reply(); !strings.HasPrefix(retry.Text, ru.T("add.bad_date", ru.T("date.hint"))) {
+		t.Fatalf("неверная дата не отклонена: %q", retry.Text)
+	}
+	srv.SendText(chat, user, "01.01.2100")
+	if retry := reply(); !strings.HasPrefix(retry.Text, ru.T("edit.retry", ru.T("error.future_birthday"))) {
+		t.Fatalf("дата в будущем не отклонена: %q", retry.Text)
+	}
+	srv.SendText(chat, user, "05.06.1995")
+	confirm = reply()
+	if want := ru.T("edit.confirm", "Анна (01.02)", "Анна", "05.06.1995"); confirm.Text != want {
+		t.Fatalf("запрос подтверждения %q, ожидалось %q", confirm.Text, want)
+	}
+	press(confirm, ru.T("button.cancel"))
+	if cancelled := reply(); cancelled.Text != ru.T("edit.cancelled") {
+		t.Fatalf("ответ на отмену %q", cancelled.Text)
+	}
+
+	// После отмены кнопка сохранения уже не действует
+	press(confirm, ru.T("button.save"))
+	if expired := reply(); expired.Text != ru.T("edit.expired") {
+		t.Fatalf("ответ на сохранение после отмены %q", expired.Text)
+	}
+
+	srv.SendText(chat, user, "/list")
+	list := reply().Text
+	for _, want := range []string{"Иван Сидоров", "15.03.1990", "Анна", "(01.02)"} {
+		if !strings.Contains(list, want) {
+			t.Errorf("в списке нет %q:\n%s", want, list)
+		}
+	}
+	if strings.Contains(list, "Иван Петров") || strings.Contains(list, "05.06.1995") {
+		t.Errorf("в списке остались старые или отмененные данные:\n%s", list)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	srv := telegramtest.NewServer()
+	s := runTestService(t, srv, &config.Config{
+		Token:       telegramtest.Token,
+		APIEndpoint: srv.Endpoint(),
+	})
+	user := &tgbotapi.User{ID: 1005, FirstName: "Ольга"}
+	chat := privateChat(user)
+
+	// 75 записей с длинными именами: три страницы списка и десять страниц клавиатуры
+	ctx := context.Background()
+	if err := s.store.EnsureGroup(ctx, &models.Group{ID: chat.ID}); err != nil {
+		t.Fatal(err)
+	}
+	birthdays := make([]*models.Birthday, 75)
+	for i := range birthdays {
+		birthdays[i] = &models.Birthday{
+			Name:     fmt.Sprintf("Александра Константиновна Преображенская %02d", i),
+			Birthday: time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 4*i),
+			GroupID:  chat.ID,
+		}
+		if err := s.store.AddBirthday(ctx, birthdays[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	name := func(i int) string { return birthdays[i].Name }
+	// deleteButton возвращает текст кнопки удаления i-й записи
+	deleteButton := func(i int) string {
+		return ru.T("delete.button", birthdays[i].Name, birthdays[i].FormatDate())
+	}
+
+	// message возвращает текущее состояние сообщения бота с учетом изменений
+	message := func(id int) tgbotapi.Message {
+		t.Helper()
+		for _, m := range srv.Messages(chat.ID) {
+			if m.MessageID == id {
+				return m
+			}
+		}
+		t.Fatalf("нет сообщения %d", id)
+		return tgbotapi.Message{}
+	}
+	// hasButton сообщает, есть ли под сообщением кнопка с текстом text
+	hasButton := func(m tgbotapi.Message, text string) bool {
+		if m.ReplyMarkup != nil {
+			for _, row := range m.ReplyMarkup.InlineKeyboard {
+				for _, button := range row {
+					if button.Text == text {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+	// checkPage проверяет ограничения Telegram и кнопки переключения страницы page из pages
+	checkPage := func(m tgbotapi.Message, page, pages int) {
+		t.Helper()
+		if len(m.Text) > maxMessageLength {
+			t.Errorf("сообщение длиной %d байт", len(m.Text))
+		}
+		for _, row := range m.ReplyMarkup.InlineKeyboard {
+			for _, button := range row {
+				if data := *button.CallbackData; len(data) > maxCallbackDataLength {
+					t.Errorf("данные кнопки %q длиной %d байт", data, len(data))
+				}
+			}
+		}
+		if !hasButton(m, fmt.Sprintf("%d/%d", page, pages)) {
+			t.Errorf("нет номера страницы %d/%d под сообщением %q", page, pages, m.Text)
+		}
+		if got := hasButton(m, ru.T("nav.prev")); got != (page > 1) {
+			t.Errorf("страница %d: кнопка назад %v", page, got)
+		}
+		if got := hasButton(m, ru.T("nav.next")); got != (page < pages) {
+			t.Errorf("страница %d: кнопка вперед %v", page, got)
+		}
+	}
+	// Список: по 30 строк на странице. Записи идут по близости дня рождения,
+	// поэтому проверяется только, что страницы не пересекаются
+	shown := func(m tgbotapi.Message) []int {
+		var result []int
+		for i := range birthdays {
+			if strings.Contains(m.Text, name(i)) {
+				result = append(result, i)
+			}
+		}
+		return result
+	}
+	srv.SendText(chat, user, "/list")
+	list := srv.WaitMessages(t, chat.ID, 1)[0]
+	checkPage(list, 1, 3)
+	first := shown(list)
+	if len(first) != 30 {
+		t.Fatalf("на первой странице списка %d записей", len(first))
+	}
+	srv.WaitCallbackAnswer(t, srv.PressButton(user, &list, findButton(t, list, ru.T("nav.next"))))
+	waitFor(t, "перехода на вторую страницу списка", func() bool {
+		return hasButton(message(list.MessageID), "2/3")
+	})
+	list = message(list.MessageID)
+	checkPage(list, 2, 3)
+	second := shown(list)
+	if len(second) != 30 || slices.ContainsFunc(second, func(i int) bool { return slices.Contains(first, i) }) {
+		t.Fatalf("вторая страница списка %v пересекается с первой %v", second, first)
+	}
+
+	// Клавиатура изменения: по 8 записей на странице
+	srv.SendText(chat, user, "/edit")
+	edit := srv.WaitMessages(t, chat.ID, 2)[1]
+	checkPage(edit, 1, 10)
+
+	// Клавиатура удаления: после удаления со второй страницы остается вторая страница
+	srv.SendText(chat, user, "/delete")
+	keyboard := srv.WaitMessages(t, chat.ID, 3)[2]
+	checkPage(keyboard, 1, 10)
+	if hasButton(keyboard, deleteButton(8)) {
+		t.Fatalf("запись второй страницы на первой")
+	}
+	srv.WaitCallbackAnswer(t, srv.PressButton(user, &keyboard, findButton(t, keyboard, ru.T("nav.next"))))
+	waitFor(t, "перехода на вторую страницу клавиатуры", func() bool {
+		return hasButton(message(keyboard.MessageID), deleteButton(8))
+	})
+	keyboard = message(keyboard.MessageID)
+	checkPage(keyboard, 2, 10)
+
+	srv.WaitCallbackAnswer(t, srv.PressButton(user, &keyboard, findButton(t, keyboard, name(8))))
+	messages := srv.WaitMessages(t, chat.ID, 4)
+	if want := ru.T("delete.done", name(8)); lastText(messages) != want {
+		t.Fatalf("ответ на удаление %q, ожидалось %q", lastText(messages), want)
+	}
+	waitFor(t, "обновления клавиатуры удаления", func() bool {
+		return hasButton(message(keyboard.MessageID), deleteButton(16))
+	})
+	keyboard = message(keyboard.MessageID)
+	checkPage(keyboard, 2, 10)
+	if hasButton(keyboard, deleteButton(8)) {
+		t.Fatalf("удаленная запись осталась на клавиатуре")
+	}
+	if !hasButton(keyboard, deleteButton(9)) {
+		t.Fatalf("вторая страница клавиатуры сдвинулась")
+	}
+}
+
+func TestGroupPermissions(t *testing.T) {
+	srv := startTestService(t)
+	admin := &tgbotapi.User{ID: 2001, FirstName: "Админ"}
+	member := &tgbotapi.User{ID: 2002, FirstName: "Участник"}
+	chat := &tgbotapi.Chat{ID: -1002003, Type: "supergroup", Title: "Друзья"}
+	srv.SetAdmins(chat.ID, admin.ID)
+
+	// Участник без прав не может начать добавление
+	srv.SendText(chat, member, "/add")
+	messages := srv.WaitMessages(t, chat.ID, 1)
+	if want := ru.T("access.denied"); lastText(messages) != want {
+		t.Fatalf("ответ участнику %q, ожидалось %q", lastText(messages), want)
+	}
+
+	// Список доступен всем, а кнопки изменения — только администраторам
+	srv.SendText(chat, member, "@"+telegramtest.BotUsername)
+	messages = srv.WaitMessages(t, chat.ID, 2)
+	menu := messages[1]
+	id := srv.PressButton(member, &menu, encodeCallback(actionAdd))
+	if answer := srv.WaitCallbackAnswer(t, id); !answer.ShowAlert || answer.Text != ru.T("access.denied") {
+		t.Fatalf("нажатие участника не отклонено: %+v", answer)
+	}
+
+	id = srv.PressButton(admin, &menu, encodeCallback(actionAdd))
+	srv.WaitCallbackAnswer(t, id)
+	messages = srv.WaitMessages(t, chat.ID, 3)
+	if !strings.HasPrefix(lastText(messages), ru.T("add.prompt")) {
+		t.Fatalf("администратору не задан вопрос: %q", lastText(messages))
+	}
+}
+
+func TestAnonymousAdmin(t *testing.T) {
+	srv := startTestService(t)
+	admin := &tgbotapi.User{ID: 2201, FirstName: "Админ"}
+	anonymous := &tgbotapi.User{ID: groupAnonymousBotID, FirstName: "Group", UserName: "GroupAnonymousBot", IsBot: true}
+	chat := &tgbotapi.Chat{ID: -1002201, Type: "supergroup", Title: "Друзья"}
+	srv.SetAdmins(chat.ID, admin.ID)
+
+	// sendAnonymous отправляет сообщение от имени группы, как его присылает анонимный администратор
+	sendAnonymous := func(id int, text string, entities []tgbotapi.MessageEntity) {
+		srv.PushUpdate(tgbotapi.Update{Message: &tgbotapi.Message{
+			MessageID:  id,
+			From:       anonymous,
+			SenderChat: chat,
+			Chat:       chat,
+			Date:       int(time.Now().Unix()),
+			Text:       text,
+			Entities:   entities,
+		}})
+	}
+
+	// Администратор начал добавление, анонимный администратор не может вклиниться в диалог
+	srv.SendText(chat, admin, "/add")
+	srv.WaitMessages(t, chat.ID, 1)
+
+	sendAnonymous(1001, "/add", []tgbotapi.MessageEntity{{Type: "bot_command", Length: len("/add")}})
+	messages := srv.WaitMessages(t, chat.ID, 2)
+	if want := ru.T("conversation.anonymous"); lastText(messages) != want {
+		t.Fatalf("ответ анонимному администратору %q, ожидалось %q", lastText(messages), want)
+	}
+	sendAnonymous(1002, "Чужое имя 01.01.2000", nil)
+
+	srv.SendText(chat, admin, "Иван Петров 15.03.1990")
+	messages = srv.WaitMessages(t, chat.ID, 3)
+	if want := ru.T("add.done", "Иван Петров"); lastText(messages) != want {
+		t.Fatalf("ответ администратору %q, ожидалось %q", lastText(messages), want)
+	}
+
+	srv.SendText(chat, admin, "/list")
+	list := lastText(srv.WaitMessages(t, chat.ID, 4))
+	if !strings.Contains(list, "Иван Петров") || strings.Contains(list, "Чужое имя") {
+		t.Fatalf("список после сообщений анонимного администратора:\n%s", list)
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	srv := startTestService(t)
+	user := &tgbotapi.User{ID: 1003, FirstName: "Kate"}
+	chat := privateChat(user)
+	en := i18n.New(i18n.English)
+
+	srv.SendText(chat, user, "/language en")
+	messages := srv.WaitMessages(t, chat.ID, 1)
+	if want := en.T("language.done", en.T("language.name")); lastText(messages) != want {
+		t.Fatalf("ответ на смену языка %q, ожидалось %q", lastText(messages), want)
+	}
+
+	srv.SendText(chat, user, "/list")
+	messages = srv.WaitMessages(t, chat.ID, 2)
+	if want := en.T("list.empty"); lastText(messages) != want {
+		t.Fatalf("список на английском %q, ожидалось %q", lastText(messages), want)
+	}
+
+	// Ошибки шаблона тоже на языке группы, без исходного текста ошибки
+	srv.SendText(chat, user, "/template set birthday {{.Name")
+	messages = srv.WaitMessages(t, chat.ID, 3)
+	if want := en.T("template.invalid", en.T("error.template_syntax")); lastText(messages) != want {
+		t.Fatalf("ошибка шаблона %q, ожидалось %q", lastText(messages), want)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	srv := telegramtest.NewServer()
+	addr := freeAddr(t)
+	webhookURL := "http://" + addr + "/telegram/webhook"
+	runTestService(t, srv, &config.Config{
+		Token:         telegramtest.Token,
+		APIEndpoint:   srv.Endpoint(),
+		Mode:          config.ModeWebhook,
+		WebhookURL:    webhookURL,
+		WebhookSecret: "test-secret",
+		ListenAddr:    addr,
+	})
+
+	if webhook := srv.Webhook(); webhook.URL != webhookURL || webhook.Secret != "test-secret" {
+		t.Fatalf("установлен webhook %+v", webhook)
+	}
+
+	// Telegram доставляет обновления на webhook с секретным токеном
+	user := &tgbotapi.User{ID: 1004, FirstName: "Ирина"}
+	chat := privateChat(user)
+	srv.SendText(chat, user, "/list")
+	messages := srv.WaitMessages(t, chat.ID, 1)
+	if want := ru.T("list.empty"); lastText(messages) != want {
+		t.Fatalf("ответ через webhook %q, ожидалось %q", lastText(messages), want)
+	}
+
+	// Запросы без секретного токена и не методом POST отклоняются
+	tests := []struct {
+		name   string
+		method string
+		secret string
+		want   int
+	}{
+		{"без токена", http.MethodPost, "", http.StatusUnauthorized},
+		{"чужой токен", http.MethodPost, "wrong-secret", http.StatusUnauthorized},
+		{"метод GET", http.MethodGet, "test-secret", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, webhookURL, strings.NewReader(`{"update_id":1}`))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if tt.secret != "" {
+			req.Header.Set(webhookSecretHeader, tt.secret)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s: статус %d, ожидался %d", tt.name, resp.StatusCode, tt.want)
+		}
+	}
+}
+
+func TestWebhookFallbackToPolling(t *testing.T) {
+	// Порт занят, поэтому webhook запустить не удается
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ошибка занятия порта: %v", err)
+	}
+	defer busy.Close()
+
+	srv := telegramtest.NewServer()
+	runTestService(t, srv, &config.Config{
+		Token:       telegramtest.Token,
+		APIEndpoint: srv.Endpoint(),
+		Mode:        config.ModeWebhook,
+		WebhookURL:  "http://" + busy.Addr().String() + "/telegram/webhook",
+		ListenAddr:  busy.Addr().String(),
+	})
+
+	if webhook := srv.Webhook(); webhook.URL != "" {
+		t.Fatalf("webhook установлен, хотя сервер не запущен: %+v", webhook)
+	}
+
+	user := &tgbotapi.User{ID: 1005, FirstName: "Павел"}
+	chat := privateChat(user)
+	srv.SendText(chat, user, "/list")
+	messages := srv.WaitMessages(t, chat.ID, 1)
+	if want := ru.T("list.empty"); lastText(messages) != want {
+		t.Fatalf("ответ через getUpdates %q, ожидалось %q", lastText(messages), want)
+	}
+}
+
+// httpGet выполняет GET-запрос и возвращает статус и тело ответа
+func httpGet(t *testing.T, url string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestHealthAndMetrics(t *testing.T) {
+	srv := telegramtest.NewServer()
+	addr := freeAddr(t)
+	s := runTestService(t, srv, &config.Config{
+		Token:       telegramtest.Token,
+		APIEndpoint: srv.Endpoint(),
+		ListenAddr:  addr,
+	})
+	base := "http://" + addr
+
+	if status, _ := httpGet(t, base+"/healthz"); status != http.StatusOK {
+		t.Fatalf("/healthz: статус %d", status)
+	}
+	if status, body := httpGet(t, base+"/readyz"); status != http.StatusOK {
+		t.Fatalf("/readyz: статус %d: %s", status, body)
+	}
+
+	user := &tgbotapi.User{ID: 1006, FirstName: "Нина"}
+	chat := privateChat(user)
+	srv.SendText(chat, user, "/list")
+	srv.SendText(chat, user, "/unknown_command")
+	srv.SendText(chat, user, "/help")
+	srv.WaitMessages(t, chat.ID, 2)
+
+	status, body := httpGet(t, base+"/metrics")
+	if status != http.StatusOK {
+		t.Fatalf("/metrics: статус %d", status)
+	}
+	for _, want := range []string{
+		"# TYPE birthday_bot_updates_total counter",
+		`birthday_bot_updates_total{kind="command",status="ok"}`,
+		`birthday_bot_commands_total{command="list"}`,
+		`birthday_bot_commands_total{command="unknown"}`,
+		"# TYPE birthday_bot_db_query_duration_seconds histogram",
+		`birthday_bot_db_query_duration_seconds_bucket{op="query",le="+Inf"}`,
+		"# TYPE birthday_bot_scheduler_tick_duration_seconds histogram",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("в метриках нет %q", want)
+		}
+	}
+
+	// Без базы данных бот не готов, но жив
+	s.store.Close()
+	if status, _ := httpGet(t, base+"/readyz"); status != http.StatusServiceUnavailable {
+		t.Errorf("/readyz без базы данных: статус %d, ожидался %d", status, http.StatusServiceUnavailable)
+	}
+	if status, _ := httpGet(t, base+"/healthz"); status != http.StatusOK {
+		t.Errorf("/healthz без базы данных: статус %d", status)
+	}
+}
+
+func TestGracefulShutdown(t *testing.T) {
+	srv := telegramtest.NewServer()
+	defer srv.Close()
+	addr := freeAddr(t)
+	webhookURL := "http://" + addr + "/telegram/webhook"
+	s := newTestService(t, srv, &config.Config{
+		Token:         telegramtest.Token,
+		APIEndpoint:   srv.Endpoint(),
+		Mode:          config.ModeWebhook,
+		WebhookURL:    webhookURL,
+		WebhookSecret: "test-secret",
+		ListenAddr:    addr,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	result := make(chan error, 1)
+	go func() { result <- s.Start(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.Webhook().URL == "" {
+		if time.Now().After(deadline) {
+			t.Fatal("webhook не установлен")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Обновления, принятые webhook, Telegram считает доставленными, поэтому все они
+	// должны быть обработаны до закрытия хранилища
+	user := &tgbotapi.User{ID: 1007, FirstName: "Глеб"}
+	chat := privateChat(user)
+	const sent = 10
+	for i := 1; i <= sent; i++ {
+		body, err := json.Marshal(tgbotapi.Update{
+			UpdateID: i,
+			Message: &tgbotapi.Message{
+				MessageID: i,
+				From:      user,
+				Chat:      chat,
+				Date:      int(time.Now().Unix()),
+				Text:      "/list",
+				Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Length: len("/list")}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("ошибка кодирования обновления: %v", err)
+		}
+		req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("ошибка создания запроса: %v", err)
+		}
+		req.Header.Set(webhookSecretHeader, "test-secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("ошибка отправки обновления: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("обновление %d: статус %d", i, resp.StatusCode)
+		}
+	}
+
+	// Сигнал завершения: Start возвращается, Stop дожидается обработки очереди
+	cancel()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Fatalf("ошибка работы сервиса: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start не вернулся после отмены контекста")
+	}
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stopCancel()
+	if err := s.Stop(stopCtx); err != nil {
+		t.Fatalf("ошибка остановки сервиса: %v", err)
+	}
+
+	if got := len(srv.Messages(chat.ID)); got != sent {
+		t.Errorf("до остановки отправлено %d ответов, ожидалось %d", got, sent)
+	}
+	if webhook := srv.Webhook(); webhook.URL != "" {
+		t.Errorf("webhook не удален при остановке: %+v", webhook)
+	}
+	if err := s.store.Ping(context.Background()); err == nil {
+		t.Error("хранилище не закрыто после остановки")
+	}
+}
+
+// waitFor ждет, пока cond не станет истинным
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("не дождались: %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// activeGroup сообщает, возвращает ли GetAllGroups группу id
+func activeGroup(t *testing.T, s *Service, id int64) bool {
+	t.Helper()
+
+	groups, err := s.store.GetAllGroups(context.Background())
+	if err != nil {
+		t.Fatalf("ошибка получения групп: %v", err)
+	}
+	for _, g := range groups {
+		if g.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGroupMigration(t *testing.T) {
+	srv := telegramtest.NewServer()
+	s := runTestService(t, srv, &config.Config{Token: telegramtest.Token, APIEndpoint: srv.Endpoint()})
+	admin := &tgbotapi.User{ID: 2101, FirstName: "Админ"}
+	group := &tgbotapi.Chat{ID: -2101, Type: "group", Title: "Семья"}
+	supergroup := &tgbotapi.Chat{ID: -1002101, Type: "supergroup", Title: "Семья"}
+	srv.SetAdmins(group.ID, admin.ID)
+	srv.SetAdmins(supergroup.ID, admin.ID)
+	en := i18n.New(i18n.English)
+
+	srv.SendText(group, admin, "/add")
+	srv.SendText(group, admin, "Иван Петров 15.03.1990")
+	srv.SendText(group, admin, "/language en")
+	srv.WaitMessages(t, group.ID, 3)
+
+	// Telegram сообщает в старой группе, что она стала супергруппой
+	srv.PushUpdate(tgbotapi.Update{Message: &tgbotapi.Message{
+		MessageID:       100,
+		From:            admin,
+		Chat:            group,
+		Date:            int(time.Now().Unix()),
+		MigrateToChatID: supergroup.ID,
+	}})
+	waitFor(t, "перенос группы", func() bool { return activeGroup(t, s, supergroup.ID) })
+	if activeGroup(t, s, group.ID) {
+		t.Error("старая группа осталась в списке групп планировщика")
+	}
+
+	// Дни рождения и язык группы доступны в супергруппе
+	srv.SendText(supergroup, admin, "/list")
+	list := lastText(srv.WaitMessages(t, supergroup.ID, 1))
+	for _, want := range []string{en.T("list.header"), "Иван Петров"} {
+		if !strings.Contains(list, want) {
+			t.Errorf("в списке супергруппы нет %q:\n%s", want, list)
+		}
+	}
+}
+
+func TestBotRemovedFromGroup(t *testing.T) {
+	srv := telegramtest.NewServer()
+	s := runTestService(t, srv, &config.Config{Token: telegramtest.Token, APIEndpoint: srv.Endpoint()})
+	admin := &tgbotapi.User{ID: 2201, FirstName: "Админ"}
+	group := &tgbotapi.Chat{ID: -1002201, Type: "supergroup", Title: "Коллеги"}
+
+	// memberUpdate возвращает обновление my_chat_member с новым статусом бота
+	memberUpdate := func(old, status string) tgbotapi.Update {
+		return tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+			Chat:          *group,
+			From:          *admin,
+			Date:          int(time.Now().Unix()),
+			OldChatMember: tgbotapi.ChatMember{User: telegramtest.Bot(), Status: old},
+			NewChatMember: tgbotapi.ChatMember{User: telegramtest.Bot(), Status: status},
+		}}
+	}
+
+	// Бота добавили в группу: она регистрируется еще до первого сообщения
+	srv.PushUpdate(memberUpdate("left", "member"))
+	waitFor(t, "регистрация группы", func() bool { return activeGroup(t, s, group.ID) })
+
+	srv.PushUpdate(memberUpdate("member", "kicked"))
+	waitFor(t, "отключение группы", func() bool { return !activeGroup(t, s, group.ID) })
+
+	// Бота вернули: данные группы сохранились, и планировщик снова ее видит
+	srv.PushUpdate(memberUpdate("kicked", "administrator"))
+	waitFor(t, "возвращение группы", func() bool { return activeGroup(t, s, group.ID) })
+}
@@ -0,0 +1,153 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"Eldarius_bot/internal/i18n"
+	"Eldarius_bot/internal/templates"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleTemplate показывает, проверяет и изменяет шаблоны уведомлений группы
+func (h *Handler) handleTemplate(ctx context.Context, message *tgbotapi.Message) error {
+	chatID := message.Chat.ID
+	l := h.localizer(ctx, chatID)
+	action, rest := cutWord(message.CommandArguments())
+	kindName, body := cutWord(rest)
+
+	if action == "" {
+		return h.sendTemplateList(ctx, chatID)
+	}
+
+	kind, ok := templates.ParseKind(kindName)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, l.T("template.usage"))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	switch action {
+	case "show":
+		current, err := h.groupTemplate(ctx, l, chatID, kind)
+		if err != nil {
+			return err
+		}
+		msg := tgbotapi.NewMessage(chatID, current)
+		_, err = h.send(ctx, msg)
+		return err
+	case "preview":
+		current, err := h.groupTemplate(ctx, l, chatID, kind)
+		if err != nil {
+			return err
+		}
+		return h.sendTemplatePreview(ctx, l, chatID, kind, current)
+	case "set", "reset":
+		// Изменять шаблоны могут только администраторы и редакторы
+		if ok, err := h.requireEditor(ctx, message); !ok {
+			return err
+		}
+	default:
+		msg := tgbotapi.NewMessage(chatID, l.T("template.usage"))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	if action == "reset" {
+		if err := h.store.DeleteTemplate(ctx, chatID, string(kind)); err != nil {
+			msg := tgbotapi.NewMessage(chatID, l.T("template.reset_error", h.errorText(ctx, l, err)))
+			_, err := h.send(ctx, msg)
+			return err
+		}
+		msg := tgbotapi.NewMessage(chatID, l.T("template.reset_done", kind))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	// Текст шаблона берется из команды или из сообщения, на которое она отвечает
+	if body == "" && message.ReplyToMessage != nil {
+		body = strings.TrimSpace(message.ReplyToMessage.Text)
+	}
+	if body == "" {
+		msg := tgbotapi.NewMessage(chatID, l.T("template.need_text"))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	// Сломанный шаблон не сохраняем, чтобы он не помешал уведомлениям
+	if err := templates.Validate(l, kind, body); err != nil {
+		msg := tgbotapi.NewMessage(chatID, l.T("template.invalid", h.errorText(ctx, l, err)))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	if err := h.store.SetTemplate(ctx, chatID, string(kind), body); err != nil {
+		msg := tgbotapi.NewMessage(chatID, l.T("template.save_error", h.errorText(ctx, l, err)))
+		_, err := h.send(ctx, msg)
+		return err
+	}
+
+	return h.sendTemplatePreview(ctx, l, chatID, kind, body)
+}
+
+// sendTemplateList показывает, какие шаблоны группа изменила
+func (h *Handler) sendTemplateList(ctx context.Context, chatID int64) error {
+	l := h.localizer(ctx, chatID)
+
+	var text strings.Builder
+	text.WriteString(l.T("template.list_header") + "\n")
+	for _, kind := range templates.Kinds() {
+		body, err := h.store.GetTemplate(ctx, chatID, string(kind))
+		if err != nil {
+			return fmt.Errorf("ошибка при получении шаблона: %w", err)
+		}
+		state := l.T("template.default")
+		if body != "" {
+			state = l.T("template.custom")
+		}
+		text.WriteString(fmt.Sprintf("- %s: %s\n", kind, state))
+	}
+	text.WriteString("\n")
+	text.WriteString(l.T("template.usage"))
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	_, err := h.send(ctx, msg)
+	return err
+}
+
+// sendTemplatePreview отправляет пример уведомления по шаблону
+func (h *Handler) sendTemplatePreview(ctx context.Context, l i18n.Localizer, chatID int64, kind templates.Kind, body string) error {
+	text, err := templates.Render(l, body, templates.Sample(l, kind))
+	if err != nil {
+		text = "❌ " + h.errorText(ctx, l, err)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, l.T("template.preview", kind, text))
+	_, err = h.send(ctx, msg)
+	return err
+}
+
+// groupTemplate возвращает шаблон группы или шаблон по умолчанию
+func (h *Handler) groupTemplate(ctx context.Context, l i18n.Localizer, chatID int64, kind templates.Kind) (string, error) {
+	body, err := h.store.GetTemplate(ctx, chatID, string(kind))
+	if err != nil {
+		return "", fmt.Errorf("ошибка при получении шаблона: %w", err)
+	}
+	if body == "" {
+		body = templates.Default(l, kind)
+	}
+	return body, nil
+}
+
+// cutWord отделяет первое слово от остального текста, сохраняя переводы строк в остатке
+func cutWord(text string) (word, rest string) {
+	text = strings.TrimSpace(text)
+	i := strings.IndexFunc(text, unicode.IsSpace)
+	if i < 0 {
+		return text, ""
+	}
+	return text[:i], strings.TrimSpace(text[i:])
+}
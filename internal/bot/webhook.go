@@ -0,0 +1,209 @@
+package bot
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"Eldarius_bot/internal/config"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// webhookSecretHeader заголовок, в котором Telegram передает секретный токен webhook
+	webhookSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+	// updatesBuffer размер очереди обновлений, как у GetUpdatesChan
+	updatesBuffer = 100
+)
+
+// startReceiving запускает HTTP-сервер и получение обновлений. Возвращает канал обновлений
+// и функцию остановки, которая закрывает канал, а затем останавливает HTTP-сервер.
+// Без HTTP-сервера бот продолжает работу, но без webhook и проверок состояния.
+func (s *Service) startReceiving() (tgbotapi.UpdatesChannel, func(), error) {
+	stopHTTP, err := s.startHTTPServer()
+	if err != nil {
+		s.logger.Warn("HTTP-сервер не запущен", slog.Any("error", err))
+	}
+
+	updates, stopUpdates, err := s.receiveUpdates(stopHTTP != nil)
+	if err != nil {
+		if stopHTTP != nil {
+			stopHTTP()
+		}
+		return nil, nil, err
+	}
+
+	stop := func() {
+		stopUpdates()
+		if stopHTTP != nil {
+			stopHTTP()
+		}
+	}
+	return updates, stop, nil
+}
+
+// receiveUpdates запускает получение обновлений способом из конфигурации и возвращает
+// канал обновлений и функцию остановки, после которой канал закрывается.
+// Если webhook запустить не удалось, бот переходит на getUpdates.
+func (s *Service) receiveUpdates(serving bool) (tgbotapi.UpdatesChannel, func(), error) {
+	if s.config.Mode == config.ModeWebhook {
+		updates, stop, err := s.startWebhook(serving)
+		if err == nil {
+			return updates, stop, nil
+		}
+		s.logger.Warn("Не удалось запустить webhook, переходим на getUpdates", slog.Any("error", err))
+	}
+
+	return s.startPolling()
+}
+
+// startPolling запускает получение обновлений методом getUpdates
+func (s *Service) startPolling() (tgbotapi.UpdatesChannel, func(), error) {
+	// Пока установлен webhook, Telegram отклоняет getUpdates
+	if err := s.deleteWebhook(); err != nil {
+		return nil, nil, err
+	}
+
+	updateConfig := tgbotapi.NewUpdate(0)
+	updateConfig.Timeout = 60
+
+	return s.bot.GetUpdatesChan(updateConfig), s.bot.StopReceivingUpdates, nil
+}
+
+// startWebhook принимает обновления на пути из WebhookURL и регистрирует webhook в Telegram.
+// Работает только при запущенном HTTP-сервере (serving). При остановке webhook удаляется,
+// чтобы до следующего запуска Telegram копил обновления у себя.
+func (s *Service) startWebhook(serving bool) (tgbotapi.UpdatesChannel, func(), error) {
+	if !serving {
+		return nil, nil, errors.New("HTTP-сервер не запущен")
+	}
+
+	u, err := url.Parse(s.config.WebhookURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ошибка разбора адреса webhook: %w", err)
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	secret := s.config.WebhookSecret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Путь начинает принимать запросы до регистрации webhook, чтобы первые обновления не потерялись
+	wh := newWebhook(secret)
+	s.mux.Handle("POST "+path, wh)
+
+	if err := s.setWebhook(secret); err != nil {
+		wh.close()
+		return nil, nil, err
+	}
+	s.logger.Info("Webhook принимает обновления", slog.String("url", s.config.WebhookURL))
+
+	stop := func() {
+		if err := s.deleteWebhook(); err != nil {
+			s.logger.Error("Ошибка удаления webhook", slog.Any("error", err))
+		}
+		wh.close()
+	}
+	return wh.updates, stop, nil
+}
+
+// setWebhook регистрирует webhook в Telegram. Метод вызывается через MakeRequest,
+// так как WebhookConfig библиотеки не умеет передавать secret_token.
+func (s *Service) setWebhook(secret string) error {
+	params := tgbotapi.Params{"url": s.config.WebhookURL, "secret_token": secret}
+	if _, err := s.bot.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("ошибка установки webhook: %w", err)
+	}
+	return nil
+}
+
+// deleteWebhook удаляет webhook, не сбрасывая накопленные обновления
+func (s *Service) deleteWebhook() error {
+	if _, err := s.bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		return fmt.Errorf("ошибка удаления webhook: %w", err)
+	}
+	return nil
+}
+
+// webhook принимает обновления от Telegram и ставит их в очередь updates.
+// Запросы без верного секретного токена отклоняются.
+type webhook struct {
+	secret  string
+	updates chan tgbotapi.Update
+	done    chan struct{} // Закрывается при остановке, чтобы освободить ожидающие запросы
+
+	mu     sync.RWMutex // Запросы держат блокировку на чтение, пока ставят обновление в очередь
+	closed bool
+}
+
+// newWebhook создает приемник обновлений с секретным токеном secret
+func newWebhook(secret string) *webhook {
+	return &webhook{
+		secret:  secret,
+		updates: make(chan tgbotapi.Update, updatesBuffer),
+		done:    make(chan struct{}),
+	}
+}
+
+// ServeHTTP принимает одно обновление
+func (wh *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(webhookSecretHeader)), []byte(wh.secret)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var update tgbotapi.Update
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	wh.mu.RLock()
+	defer wh.mu.RUnlock()
+	if wh.closed {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case wh.updates <- update:
+		w.WriteHeader(http.StatusOK)
+	case <-wh.done:
+		// Telegram повторит обновление после следующего запуска
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+	case <-r.Context().Done():
+	}
+}
+
+// close перестает принимать обновления и закрывает очередь
+func (wh *webhook) close() {
+	close(wh.done)
+
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	wh.closed = true
+	close(wh.updates)
+}
+
+// newWebhookSecret создает случайный секретный токен webhook
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ошибка создания секретного токена webhook: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
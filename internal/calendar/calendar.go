@@ -0,0 +1,125 @@
+// Package calendar вычисляет ближайшие дни рождения с учетом календаря:
+// перехода через границу года, 29 февраля в невисокосные годы и часового пояса.
+// Списки в чате, планировщик и хранилище используют только этот пакет,
+// чтобы расчеты не расходились между собой.
+package calendar
+
+import (
+	"sort"
+	"time"
+
+	"Eldarius_bot/internal/models"
+)
+
+// Occurrence описывает ближайшее наступление дня рождения
+type Occurrence struct {
+	Birthday  *models.Birthday
+	Date      time.Time // Дата ближайшего дня рождения (полночь в часовом поясе now)
+	DaysUntil int       // Количество календарных дней до него, 0 — сегодня
+}
+
+// Today возвращает начало текущих суток в часовом поясе now
+func Today(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
+
+// isLeap проверяет, является ли год високосным
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// occurrenceIn возвращает дату дня рождения в указанном году.
+// Родившиеся 29 февраля в невисокосные годы празднуют 28 февраля.
+func occurrenceIn(birthday time.Time, year int, loc *time.Location) time.Time {
+	day := birthday.Day()
+	if birthday.Month() == time.February && day == 29 && !isLeap(year) {
+		day = 28
+	}
+	return time.Date(year, birthday.Month(), day, 0, 0, 0, 0, loc)
+}
+
+// NextOccurrence возвращает дату ближайшего дня рождения, начиная с сегодняшнего дня
+func NextOccurrence(birthday time.Time, now time.Time) time.Time {
+	today := Today(now)
+	next := occurrenceIn(birthday, today.Year(), now.Location())
+	if next.Before(today) {
+		next = occurrenceIn(birthday, today.Year()+1, now.Location())
+	}
+	return next
+}
+
+// DaysBetween возвращает количество календарных дней от from до to.
+// Расчет не зависит от перехода на летнее время в часовом поясе дат.
+func DaysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// DaysUntil возвращает количество дней до ближайшего дня рождения
+func DaysUntil(birthday time.Time, now time.Time) int {
+	return DaysBetween(Today(now), NextOccurrence(birthday, now))
+}
+
+// Next возвращает ближайшее наступление дня рождения
+func Next(b *models.Birthday, now time.Time) Occurrence {
+	date := NextOccurrence(b.Birthday, now)
+	return Occurrence{
+		Birthday:  b,
+		Date:      date,
+		DaysUntil: DaysBetween(Today(now), date),
+	}
+}
+
+// Age возвращает, сколько лет исполняется в этот день рождения.
+// Если год рождения неизвестен, возраст не определен и возвращается false.
+func (o Occurrence) Age() (int, bool) {
+	if o.Birthday.YearUnknown {
+		return 0, false
+	}
+	return o.Date.Year() - o.Birthday.Birthday.Year(), true
+}
+
+// IsJubilee проверяет, является ли возраст юбилейным: совершеннолетие,
+// круглые даты начиная с 20 лет и каждые четверть века (25, 75)
+func IsJubilee(age int) bool {
+	return age == 18 || (age >= 20 && age%10 == 0) || (age > 0 && age%25 == 0)
+}
+
+// Jubilee проверяет, является ли этот день рождения юбилеем.
+// Без известного года рождения юбилей определить нельзя.
+func (o Occurrence) Jubilee() bool {
+	age, ok := o.Age()
+	return ok && IsJubilee(age)
+}
+
+// Sort возвращает ближайшие наступления всех дней рождения,
+// упорядоченные от ближайшего к самому дальнему
+func Sort(birthdays []*models.Birthday, now time.Time) []Occurrence {
+	occurrences := make([]Occurrence, 0, len(birthdays))
+	for _, b := range birthdays {
+		occurrences = append(occurrences, Next(b, now))
+	}
+
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		if occurrences[i].DaysUntil != occurrences[j].DaysUntil {
+			return occurrences[i].DaysUntil < occurrences[j].DaysUntil
+		}
+		return occurrences[i].Birthday.Name < occurrences[j].Birthday.Name
+	})
+
+	return occurrences
+}
+
+// Upcoming возвращает дни рождения, наступающие в ближайшие days дней
+// (включая сегодняшний), упорядоченные от ближайшего к самому дальнему
+func Upcoming(birthdays []*models.Birthday, now time.Time, days int) []Occurrence {
+	var upcoming []Occurrence
+	for _, o := range Sort(birthdays, now) {
+		if o.DaysUntil > days {
+			break
+		}
+		upcoming = append(upcoming, o)
+	}
+	return upcoming
+}
@@ -0,0 +1,253 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"Eldarius_bot/internal/models"
+)
+
+// mustLocation загружает часовой пояс или прерывает тест
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("часовой пояс %s: %v", name, err)
+	}
+	return loc
+}
+
+// date возвращает полночь UTC указанного дня, как даты рождения хранятся в базе
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name        string
+		tz          string
+		now         string // Местное время в часовом поясе tz
+		birthday    time.Time
+		yearUnknown bool
+		wantDate    string // Дата ближайшего дня рождения
+		wantDays    int
+		wantAge     int // 0 — возраст не определен
+	}{
+		{
+			name:     "сегодня",
+			tz:       "Europe/Moscow",
+			now:      "2026-05-10 12:00",
+			birthday: date(1990, time.May, 10),
+			wantDate: "2026-05-10", wantDays: 0, wantAge: 36,
+		},
+		{
+			name:     "завтра",
+			tz:       "Europe/Moscow",
+			now:      "2026-05-10 12:00",
+			birthday: date(1990, time.May, 11),
+			wantDate: "2026-05-11", wantDays: 1, wantAge: 36,
+		},
+		{
+			name:     "вчера — следующий через год",
+			tz:       "Europe/Moscow",
+			now:      "2026-05-10 12:00",
+			birthday: date(1990, time.May, 9),
+			wantDate: "2027-05-09", wantDays: 364, wantAge: 37,
+		},
+		{
+			name:     "переход через год: из декабря в январь",
+			tz:       "Europe/Moscow",
+			now:      "2026-12-28 09:00",
+			birthday: date(1985, time.January, 2),
+			wantDate: "2027-01-02", wantDays: 5, wantAge: 42,
+		},
+		{
+			name:     "переход через год: 31 декабря и 1 января",
+			tz:       "Europe/Moscow",
+			now:      "2026-12-31 23:59",
+			birthday: date(2000, time.January, 1),
+			wantDate: "2027-01-01", wantDays: 1, wantAge: 27,
+		},
+		{
+			name:     "за минуту до полуночи день рождения еще завтра",
+			tz:       "Asia/Yekaterinburg",
+			now:      "2026-07-14 23:59",
+			birthday: date(1995, time.July, 15),
+			wantDate: "2026-07-15", wantDays: 1, wantAge: 31,
+		},
+		{
+			name:     "в полночь день рождения уже сегодня",
+			tz:       "Asia/Yekaterinburg",
+			now:      "2026-07-15 00:00",
+			birthday: date(1995, time.July, 15),
+			wantDate: "2026-07-15", wantDays: 0, wantAge: 31,
+		},
+		{
+			name:     "29 февраля в невисокосный год празднуется 28 февраля",
+			tz:       "Europe/Moscow",
+			now:      "2027-02-28 10:00",
+			birthday: date(2000, time.February, 29),
+			wantDate: "2027-02-28", wantDays: 0, wantAge: 27,
+		},
+		{
+			name:     "29 февраля в високосный год",
+			tz:       "Europe/Moscow",
+			now:      "2028-02-28 10:00",
+			birthday: date(2000, time.February, 29),
+			wantDate: "2028-02-29", wantDays: 1, wantAge: 28,
+		},
+		{
+			name:     "29 февраля после 28 февраля невисокосного года",
+			tz:       "Europe/Moscow",
+			now:      "2027-03-01 10:00",
+			birthday: date(2000, time.February, 29),
+			wantDate: "2028-02-29", wantDays: 365, wantAge: 28,
+		},
+		{
+			name:     "1 марта в високосный год",
+			tz:       "Europe/Moscow",
+			now:      "2028-02-28 10:00",
+			birthday: date(1999, time.March, 1),
+			wantDate: "2028-03-01", wantDays: 2, wantAge: 29,
+		},
+		{
+			name:     "переход на летнее время: в сутках 23 часа",
+			tz:       "Europe/Berlin",
+			now:      "2026-03-28 12:00",
+			birthday: date(1980, time.March, 30),
+			wantDate: "2026-03-30", wantDays: 2, wantAge: 46,
+		},
+		{
+			name:     "переход на зимнее время: в сутках 25 часов",
+			tz:       "America/New_York",
+			now:      "2026-10-31 23:30",
+			birthday: date(1970, time.November, 2),
+			wantDate: "2026-11-02", wantDays: 2, wantAge: 56,
+		},
+		{
+			name:     "часовой пояс впереди UTC: в UTC еще вчера",
+			tz:       "Pacific/Auckland",
+			now:      "2026-06-01 08:00",
+			birthday: date(2001, time.June, 1),
+			wantDate: "2026-06-01", wantDays: 0, wantAge: 25,
+		},
+		{
+			name:        "год рождения неизвестен",
+			tz:          "Europe/Moscow",
+			now:         "2026-12-30 09:00",
+			birthday:    date(models.UnknownYear, time.January, 3),
+			yearUnknown: true,
+			wantDate:    "2027-01-03", wantDays: 4, wantAge: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := mustLocation(t, tt.tz)
+			now, err := time.ParseInLocation("2006-01-02 15:04", tt.now, loc)
+			if err != nil {
+				t.Fatalf("время %q: %v", tt.now, err)
+			}
+
+			o := Next(&models.Birthday{Birthday: tt.birthday, YearUnknown: tt.yearUnknown}, now)
+			if got := o.Date.Format("2006-01-02"); got != tt.wantDate {
+				t.Errorf("дата %s, ожидалась %s", got, tt.wantDate)
+			}
+			if o.Date.Location() != loc {
+				t.Errorf("дата в часовом поясе %s, ожидался %s", o.Date.Location(), loc)
+			}
+			if o.DaysUntil != tt.wantDays {
+				t.Errorf("дней до дня рождения %d, ожидалось %d", o.DaysUntil, tt.wantDays)
+			}
+			if got := DaysUntil(tt.birthday, now); got != tt.wantDays {
+				t.Errorf("DaysUntil %d, ожидалось %d", got, tt.wantDays)
+			}
+
+			age, ok := o.Age()
+			if ok != (tt.wantAge != 0) || age != tt.wantAge {
+				t.Errorf("возраст %d (%v), ожидался %d", age, ok, tt.wantAge)
+			}
+		})
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     int
+	}{
+		{"один день", date(2026, time.May, 1), date(2026, time.May, 2), 1},
+		{"через год", date(2026, time.December, 31), date(2027, time.January, 1), 1},
+		{"високосный февраль", date(2028, time.February, 28), date(2028, time.March, 1), 2},
+		{"невисокосный февраль", date(2027, time.February, 28), date(2027, time.March, 1), 1},
+		{"время суток не учитывается", time.Date(2026, time.May, 1, 23, 59, 0, 0, time.UTC), date(2026, time.May, 2), 1},
+		{
+			"переход на летнее время",
+			time.Date(2026, time.March, 29, 0, 0, 0, 0, berlin),
+			time.Date(2026, time.March, 30, 0, 0, 0, 0, berlin),
+			1,
+		},
+		{
+			"переход на зимнее время",
+			time.Date(2026, time.October, 25, 0, 0, 0, 0, berlin),
+			time.Date(2026, time.October, 26, 0, 0, 0, 0, berlin),
+			1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DaysBetween(tt.from, tt.to); got != tt.want {
+				t.Errorf("DaysBetween = %d, ожидалось %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	loc := mustLocation(t, "Europe/Moscow")
+	now := time.Date(2026, time.December, 29, 10, 0, 0, 0, loc)
+
+	birthdays := []*models.Birthday{
+		{Name: "Февраль", Birthday: date(1990, time.February, 1)},
+		{Name: "Новый год", Birthday: date(1990, time.January, 1)},
+		{Name: "Сегодня", Birthday: date(1990, time.December, 29)},
+		{Name: "Вчера", Birthday: date(1990, time.December, 28)},
+		{Name: "Через неделю", Birthday: date(1990, time.January, 5)},
+	}
+
+	var got []string
+	for _, o := range Upcoming(birthdays, now, 7) {
+		got = append(got, o.Birthday.Name)
+	}
+
+	want := []string{"Сегодня", "Новый год", "Через неделю"}
+	if len(got) != len(want) {
+		t.Fatalf("ближайшие дни рождения %v, ожидалось %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ближайшие дни рождения %v, ожидалось %v", got, want)
+		}
+	}
+}
+
+func TestIsJubilee(t *testing.T) {
+	tests := []struct {
+		age  int
+		want bool
+	}{
+		{0, false}, {10, false}, {17, false}, {18, true}, {19, false}, {20, true},
+		{25, true}, {30, true}, {33, false}, {50, true}, {75, true}, {85, false}, {100, true},
+	}
+
+	for _, tt := range tests {
+		if got := IsJubilee(tt.age); got != tt.want {
+			t.Errorf("IsJubilee(%d) = %v, ожидалось %v", tt.age, got, tt.want)
+		}
+	}
+}
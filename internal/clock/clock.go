@@ -0,0 +1,53 @@
+// Package clock дает текущее время через интерфейс, чтобы расчеты дат
+// в планировщике, обработчике и хранилище можно было проверить на любой момент времени.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock источник текущего времени
+type Clock interface {
+	Now() time.Time
+}
+
+// Real системные часы
+type Real struct{}
+
+// Now возвращает текущее системное время
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Fake часы для тестов: время меняется только вызовами Set и Advance
+type Fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFake создает часы, показывающие now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now возвращает установленное время
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Set переводит часы на now
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+}
+
+// Advance переводит часы вперед на d
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
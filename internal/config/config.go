@@ -2,15 +2,45 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"regexp"
+	"time"
 
+	"Eldarius_bot/internal/logging"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
 )
 
+// Способы получения обновлений от Telegram
+const (
+	ModePolling = "polling" // Запросы getUpdates
+	ModeWebhook = "webhook" // Telegram сам присылает обновления на WebhookURL
+)
+
+// defaultShutdownTimeout время на завершение начатой работы при остановке. Меньше 10 секунд,
+// которые Docker ждет после SIGTERM, прежде чем завершить процесс принудительно.
+const defaultShutdownTimeout = 8 * time.Second
+
+// webhookSecretPattern допустимые символы секретного токена webhook по требованиям Telegram
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
 // Config содержит конфигурацию приложения
 type Config struct {
-	Token string // Токен Telegram бота
-	Debug bool   // Режим отладки
+	Token         string     // Токен Telegram бота
+	Debug         bool       // Режим отладки
+	DatabasePath  string     // Путь к файлу базы данных SQLite
+	APIEndpoint   string     // Шаблон адреса Bot API, например локального сервера Bot API
+	Mode          string     // Способ получения обновлений: ModePolling или ModeWebhook
+	WebhookURL    string     // Публичный адрес, на который Telegram отправляет обновления
+	WebhookSecret string     // Секретный токен, которым Telegram подписывает запросы к webhook
+	ListenAddr    string     // Адрес встроенного HTTP-сервера
+	LogLevel      slog.Level // Минимальный уровень записей журнала
+	LogFormat     string     // Формат журнала: logging.FormatText или logging.FormatJSON
+
+	ShutdownTimeout time.Duration // Сколько ждать завершения начатой работы при остановке
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -27,8 +57,94 @@ func Load() (*Config, error) {
 	// Получаем режим отладки
 	debug := os.Getenv("DEBUG") == "true"
 
+	// Получаем путь к базе данных
+	dbPath := os.Getenv("DATABASE_PATH")
+	if dbPath == "" {
+		dbPath = "birthdays.db"
+	}
+
+	// Получаем адрес Bot API: по умолчанию api.telegram.org
+	apiEndpoint := os.Getenv("TELEGRAM_API_ENDPOINT")
+	if apiEndpoint == "" {
+		apiEndpoint = tgbotapi.APIEndpoint
+	}
+
+	// Получаем адрес webhook: если он указан, по умолчанию бот работает через webhook
+	webhookURL := os.Getenv("WEBHOOK_URL")
+	mode := os.Getenv("BOT_MODE")
+	if mode == "" {
+		mode = ModePolling
+		if webhookURL != "" {
+			mode = ModeWebhook
+		}
+	}
+
+	switch mode {
+	case ModePolling:
+	case ModeWebhook:
+		if webhookURL == "" {
+			return nil, fmt.Errorf("для режима webhook не указан адрес (WEBHOOK_URL)")
+		}
+		u, err := url.Parse(webhookURL)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("некорректный адрес webhook (WEBHOOK_URL): %q", webhookURL)
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный режим работы (BOT_MODE): %q, допустимо %s или %s", mode, ModePolling, ModeWebhook)
+	}
+
+	// Получаем секретный токен webhook: без него токен создается при каждом запуске
+	webhookSecret := os.Getenv("WEBHOOK_SECRET")
+	if webhookSecret != "" && !webhookSecretPattern.MatchString(webhookSecret) {
+		return nil, fmt.Errorf("секретный токен webhook (WEBHOOK_SECRET) может содержать только A-Z, a-z, 0-9, _ и - и быть не длиннее 256 символов")
+	}
+
+	// Получаем адрес HTTP-сервера: по умолчанию порт 80, открытый в Dockerfile и amvera.yaml
+	listenAddr := os.Getenv("LISTEN_ADDR")
+	if listenAddr == "" {
+		listenAddr = ":80"
+	}
+
+	// Получаем уровень и формат журнала
+	logLevel := slog.LevelInfo
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		level, err := logging.ParseLevel(v)
+		if err != nil {
+			return nil, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+		logLevel = level
+	}
+	logFormat := os.Getenv("LOG_FORMAT")
+	switch logFormat {
+	case "":
+		logFormat = logging.FormatText
+	case logging.FormatText, logging.FormatJSON:
+	default:
+		return nil, fmt.Errorf("неизвестный формат журнала (LOG_FORMAT): %q, допустимо %s или %s", logFormat, logging.FormatText, logging.FormatJSON)
+	}
+
+	// Получаем время на завершение работы при остановке
+	shutdownTimeout := defaultShutdownTimeout
+	if v := os.Getenv("SHUTDOWN_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("некорректное время остановки (SHUTDOWN_TIMEOUT): %q, ожидается например 8s", v)
+		}
+		shutdownTimeout = d
+	}
+
 	return &Config{
-		Token: token,
-		Debug: debug,
+		Token:         token,
+		Debug:         debug,
+		DatabasePath:  dbPath,
+		APIEndpoint:   apiEndpoint,
+		Mode:          mode,
+		WebhookURL:    webhookURL,
+		WebhookSecret: webhookSecret,
+		ListenAddr:    listenAddr,
+		LogLevel:      logLevel,
+		LogFormat:     logFormat,
+
+		ShutdownTimeout: shutdownTimeout,
 	}, nil
 }
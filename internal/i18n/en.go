@@ -0,0 +1,210 @@
+package i18n
+
+// en английский каталог текстов
+var en = map[string]string{
+	// Склонения и даты
+	"plural.days.one":    "day",
+	"plural.days.other":  "days",
+	"plural.years.one":   "year",
+	"plural.years.other": "years",
+	"month.1":            "January",
+	"month.2":            "February",
+	"month.3":            "March",
+	"month.4":            "April",
+	"month.5":            "May",
+	"month.6":            "June",
+	"month.7":            "July",
+	"month.8":            "August",
+	"month.9":            "September",
+	"month.10":           "October",
+	"month.11":           "November",
+	"month.12":           "December",
+	"date.day_month":     "%[2]s %[1]d",
+	"date.hint":          "DD.MM.YYYY, or DD.MM if the year is unknown",
+
+	// Главное меню и общие кнопки
+	"start.greeting":         "Hi! I keep track of birthdays. Mention me in a group to get started.",
+	"menu.prompt":            "Choose an action:",
+	"menu.show":              "📅 Show birthdays",
+	"menu.add":               "➕ Add a birthday",
+	"menu.edit":              "✏️ Edit a birthday",
+	"menu.delete":            "❌ Delete a birthday",
+	"nav.prev":               "« Back",
+	"nav.next":               "Next »",
+	"button.save":            "✅ Save",
+	"button.cancel":          "✖️ Cancel",
+	"callback.outdated":      "This button is outdated",
+	"callback.outdated_menu": "⌛ This button is outdated. Choose an action:",
+	"access.denied":          "⛔ Only group admins and appointed editors can change birthdays and settings.",
+	"answer.yes_no":          "Please answer “yes” or “no”.",
+	"answer.text_only":       "Please reply with a text message.",
+	"conversation.cancel":    "Send /cancel to cancel",
+	"conversation.none":      "Nothing to cancel.",
+	"conversation.cancelled": "✖️ Cancelled.",
+	"conversation.anonymous": "🕶 The bot can't tell anonymous admins apart, so it can't run step-by-step actions for them. Turn off “Remain anonymous” in your admin settings and send the command again.",
+	"help.text": `Available commands:
+/start - Show the main menu
+/list - Show the list of birthdays
+/add - Add a birthday
+/edit - Edit a birthday
+/delete - Delete a birthday
+/cancel - Cancel the current action
+/remind - Remind about birthdays
+/timezone - Show or change the group time zone
+/reminders - Show or change when reminders are sent
+/editors - Show editors; /editors add|remove in reply to a user's message or with their ID
+/template - Show and change notification templates
+/greetings - Show and extend the greeting library
+/language - Show or change the bot language in the group
+/help - Show this message
+
+Group admins and appointed editors can change birthdays and settings.
+You can also mention the bot (@username) to open the menu.`,
+
+	// Список дней рождения
+	"list.empty":    "📝 There are no birthdays in this group yet.",
+	"list.header":   "📅 Birthdays in the group:",
+	"list.today":    "🎉 %s - TODAY! (%s)",
+	"list.upcoming": "🎂 %s - in %d %s (%s)",
+	"list.error":    "❌ Failed to load birthdays: %s",
+
+	// Добавление
+	"add.prompt":      "Enter the first and last name.\nYou can add the birth date right away: Name Surname DD.MM.YYYY\nIf the year is unknown, give only the day and month: Name Surname DD.MM",
+	"add.date_prompt": "Enter %s's birth date as %s:",
+	"add.bad_date":    "Invalid date format. Use: %s",
+	"add.error":       "Failed to add the birthday: %s",
+	"add.done":        "✅ %s's birthday has been added!",
+
+	// Удаление
+	"delete.keyboard":      "🗑 Choose a birthday to delete:",
+	"delete.button":        "❌ %s (%s)",
+	"delete.manual":        "⌨️ Type the name",
+	"delete.name_prompt":   "Enter the first and last name of the person whose birthday should be deleted:",
+	"delete.name_notfound": "❌ Birthday not found. Check the first and last name.",
+	"delete.confirm":       "Delete %s's birthday (%s)? Answer “yes” or “no”.",
+	"delete.cancelled":     "✖️ Deletion cancelled.",
+	"delete.not_found":     "❌ Birthday not found",
+	"delete.error":         "❌ Failed to delete the birthday: %s",
+	"delete.done":          "✅ %s's birthday has been deleted!",
+
+	// Изменение
+	"edit.keyboard":     "✏️ Choose a birthday to edit:",
+	"edit.button":       "✏️ %s (%s)",
+	"edit.choose_field": "What should be changed for %s (%s)?",
+	"edit.field_name":   "👤 Name",
+	"edit.field_date":   "📅 Date",
+	"edit.name_prompt":  "Current name: %s\nEnter the new name:",
+	"edit.date_prompt":  "Current birth date of %s: %s\nEnter the new date as %s:",
+	"edit.retry":        "❌ %s. Please try again.",
+	"edit.confirm":      "Save the changes?\nWas: %s\nWill be: %s (%s)",
+	"edit.expired":      "⌛ The edit was not found or has timed out. Start again: /edit",
+	"edit.cancelled":    "✖️ Edit cancelled.",
+	"edit.error":        "❌ Failed to edit the birthday: %s",
+	"edit.done":         "✅ %s's birthday (%s) has been saved!",
+
+	// Часовой пояс
+	"timezone.current":    "🕰 Group time zone: %s\n\nTo change it, send: /timezone Europe/London",
+	"timezone.unknown":    "❌ Unknown time zone: %s\nUse a name from the IANA database, e.g. Europe/London or America/New_York",
+	"timezone.save_error": "❌ Failed to save the time zone: %s",
+	"timezone.done":       "✅ Group time zone changed to %s",
+
+	// Напоминания
+	"reminders.current": "🔔 Reminders: %s\n\n" +
+		"To change them, list the days before the birthday: /reminders 14 7 1 0\n" +
+		"To restore the defaults: /reminders reset",
+	"reminders.bad_days":   "❌ Invalid number of days: %s",
+	"reminders.save_error": "❌ Failed to save reminders: %s",
+	"reminders.done":       "✅ Reminders: %s",
+	"reminders.on_day":     "on the day",
+	"reminders.before":     "%d %s before",
+
+	// Редакторы
+	"editors.none":        "👥 No additional editors. Only group admins can change data.",
+	"editors.header":      "👥 Group editors (besides admins):",
+	"editors.admins_only": "⛔ Only group admins can manage editors.",
+	"editors.usage":       "Usage: /editors add|remove in reply to a user's message or /editors add|remove <user ID>",
+	"editors.added":       "✅ %s can now change birthdays.",
+	"editors.removed":     "✅ %s can no longer change birthdays.",
+	"editors.error":       "❌ Failed to change the editor list: %s",
+
+	// Шаблоны уведомлений
+	"template.usage": `Notification templates: birthday — congratulation, jubilee — milestone congratulation, reminder — reminder.
+
+/template show <kind> - show the template
+/template preview <kind> - show a sample notification
+/template set <kind> <text> - set the template (the text can also be sent as a reply)
+/template reset <kind> - restore the default template
+
+Available fields: {{.Name}} - name, {{.Age}} - age (0 if the year is unknown),
+{{.DaysUntil}} - days until the birthday, {{.When}} - when in words, {{.Date}} - date, {{.Jubilee}} - whether it is a milestone,
+{{.Greeting}} - a greeting from the group library (/greetings).
+Plurals: {{years .Age}}, {{days .DaysUntil}}. Conditions: {{if .Age}}...{{end}}.`,
+	"template.list_header": "📝 Group notification templates:",
+	"template.default":     "default",
+	"template.custom":      "custom",
+	"template.reset_error": "❌ Failed to reset the template: %s",
+	"template.reset_done":  "✅ %s uses the default template again.",
+	"template.need_text":   "Provide the template text: /template set <kind> <text>",
+	"template.invalid":     "❌ Template not saved: %s",
+	"template.save_error":  "❌ Failed to save the template: %s",
+	"template.preview":     "👀 Sample %s notification:\n\n%s",
+
+	// Шаблоны уведомлений по умолчанию
+	"template.birthday": "🎉 Happy Birthday, {{.Name}}!{{if .Age}} Today you turn {{.Age}}!{{end}} 🎉" +
+		"{{with .Greeting}}\n\n{{.}}{{end}}",
+	"template.jubilee": "🎊 Happy milestone birthday, {{.Name}}! Today you turn {{.Age}}! 🎊\n\n" +
+		"A round number is a special occasion to look back and make your dearest wish.{{with .Greeting}} {{.}}{{end}}",
+	"template.reminder": "📅 {{.When}}: {{.Name}}'s birthday ({{.Date}})" +
+		"{{if .Age}}, turning {{.Age}}{{end}}{{if .Jubilee}} — a milestone! 🎊{{end}}",
+	"template.sample_name": "Anna Smith",
+	"template.sample_greeting": "May this day be special and full of happy moments! " +
+		"We wish you happiness, success in everything you do and all your wishes coming true! 🌟",
+
+	// Срок до дня рождения в уведомлениях
+	"when.today":     "Today",
+	"when.tomorrow":  "Tomorrow",
+	"when.day_after": "The day after tomorrow",
+	"when.week":      "In a week",
+	"when.two_weeks": "In two weeks",
+	"when.days":      "In %d %s",
+
+	// Библиотека поздравлений
+	"greetings.usage": `/greetings - show the greeting library
+/greetings add <text> - add a greeting; in reply to a sticker or GIF it will be sent along with the text
+/greetings remove <number> - delete a group greeting`,
+	"greetings.header":       "💌 Group greetings. On a birthday the bot picks one at random, avoiding the most recent ones:",
+	"greetings.sticker":      " + sticker",
+	"greetings.animation":    " + GIF",
+	"greetings.builtin":      " (built-in)",
+	"greetings.added":        "✅ Greeting #%d added.",
+	"greetings.add_error":    "❌ Failed to add the greeting: %s",
+	"greetings.removed":      "✅ Greeting #%d deleted.",
+	"greetings.remove_error": "❌ Failed to delete the greeting: %s. Built-in greetings cannot be deleted.",
+
+	// Язык
+	"language.name":    "English",
+	"language.current": "🌐 Bot language in the group: %s\n\nTo change it, send: /language ru\nAvailable languages: %s",
+	"language.unknown": "❌ Unknown language: %s. Available languages: %s",
+	"language.error":   "❌ Failed to save the language: %s",
+	"language.done":    "✅ Bot language in the group: %s",
+
+	// Ошибки проверки данных
+	"error.empty_name":        "the name cannot be empty",
+	"error.empty_birthday":    "the birth date cannot be empty",
+	"error.future_birthday":   "the birth date cannot be in the future",
+	"error.birthday_too_old":  "the birth date is too old",
+	"error.birthday_notfound": "birthday not found",
+	"error.birthday_limit":    "the group has reached its birthday limit",
+	"error.too_many_reminder": "too many reminders",
+	"error.reminder_range":    "a reminder must be between 0 and 365 days before",
+	"error.editor_notfound":   "editor not found",
+	"error.greeting_notfound": "greeting not found",
+	"error.greeting_limit":    "the group has reached its greeting limit",
+	"error.greeting_empty":    "the greeting cannot be empty",
+	"error.template_syntax":   "the template has a syntax error: check the curly braces and field names",
+	"error.template_execute":  "the template could not be filled in: check the field and function names",
+	"error.template_empty":    "the template produces an empty text",
+	"error.template_too_long": "the template or its text does not fit into one Telegram message",
+	"error.template_range":    "range loops are not supported in templates",
+	"error.internal":          "internal error, please try again later",
+}
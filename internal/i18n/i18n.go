@@ -0,0 +1,124 @@
+// Package i18n содержит каталог текстов бота на поддерживаемых языках,
+// правила склонения слов после числительных и названия месяцев.
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lang код языка
+type Lang string
+
+// Поддерживаемые языки
+const (
+	Russian Lang = "ru"
+	English Lang = "en"
+)
+
+// Default язык групп, которые его не выбирали
+const Default = Russian
+
+// catalogs тексты бота по языкам. Ключи, которых нет в каталоге языка, берутся из русского каталога.
+var catalogs = map[Lang]map[string]string{
+	Russian: ru,
+	English: en,
+}
+
+// Languages возвращает поддерживаемые языки в порядке показа пользователю
+func Languages() []Lang {
+	return []Lang{Russian, English}
+}
+
+// Parse возвращает язык по коду, например "ru" или "EN"
+func Parse(code string) (Lang, bool) {
+	lang := Lang(strings.ToLower(strings.TrimSpace(code)))
+	_, ok := catalogs[lang]
+	return lang, ok
+}
+
+// Localizer формирует тексты на одном языке
+type Localizer struct {
+	lang Lang
+}
+
+// New создает Localizer для языка. Для неизвестного языка используется язык по умолчанию.
+func New(lang Lang) Localizer {
+	if _, ok := catalogs[lang]; !ok {
+		lang = Default
+	}
+	return Localizer{lang: lang}
+}
+
+// Lang возвращает язык текстов
+func (l Localizer) Lang() Lang {
+	return l.lang
+}
+
+// T возвращает текст по ключу, подставляя аргументы как в fmt.Sprintf
+func (l Localizer) T(key string, args ...any) string {
+	format, ok := catalogs[l.lang][key]
+	if !ok {
+		format, ok = catalogs[Default][key]
+	}
+	if !ok {
+		// Ключ без перевода виден сразу, но не ломает отправку сообщения
+		return key
+	}
+
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Plural возвращает форму слова word для числа n по правилам языка.
+// Формы хранятся в каталоге под ключами word.one, word.few, word.many и word.other.
+func (l Localizer) Plural(n int, word string) string {
+	return l.T(word + "." + pluralForm(l.lang, n))
+}
+
+// Days возвращает склонение слова "день" для числа n
+func (l Localizer) Days(n int) string {
+	return l.Plural(n, "plural.days")
+}
+
+// Years возвращает склонение слова "год" для числа n
+func (l Localizer) Years(n int) string {
+	return l.Plural(n, "plural.years")
+}
+
+// Month возвращает название месяца в форме, нужной для даты: "января" или "January"
+func (l Localizer) Month(month time.Month) string {
+	return l.T("month." + strconv.Itoa(int(month)))
+}
+
+// DayMonth возвращает день и месяц даты: "2 января" или "January 2"
+func (l Localizer) DayMonth(t time.Time) string {
+	return l.T("date.day_month", t.Day(), l.Month(t.Month()))
+}
+
+// pluralForm возвращает категорию множественного числа для n
+func pluralForm(lang Lang, n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	switch lang {
+	case Russian:
+		if n%10 == 1 && n%100 != 11 {
+			return "one"
+		}
+		if n%10 >= 2 && n%10 <= 4 && (n%100 < 10 || n%100 >= 20) {
+			return "few"
+		}
+		return "many"
+	default:
+		if n == 1 {
+			return "one"
+		}
+		return "other"
+	}
+}
@@ -0,0 +1,120 @@
+package i18n
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPlural(t *testing.T) {
+	ru, en := New(Russian), New(English)
+
+	tests := []struct {
+		n         int
+		wantRu    string // Склонение слова "год"
+		wantRuDay string // Склонение слова "день"
+		wantEn    string
+	}{
+		{n: 0, wantRu: "лет", wantRuDay: "дней", wantEn: "years"},
+		{n: 1, wantRu: "год", wantRuDay: "день", wantEn: "year"},
+		{n: 2, wantRu: "года", wantRuDay: "дня", wantEn: "years"},
+		{n: 3, wantRu: "года", wantRuDay: "дня", wantEn: "years"},
+		{n: 4, wantRu: "года", wantRuDay: "дня", wantEn: "years"},
+		{n: 5, wantRu: "лет", wantRuDay: "дней", wantEn: "years"},
+		{n: 11, wantRu: "лет", wantRuDay: "дней", wantEn: "years"},
+		{n: 12, wantRu: "лет", wantRuDay: "дней", wantEn: "years"},
+		{n: 13, wantRu: "лет", wantRuDay: "дней", wantEn: "years"},
+		{n: 14, wantRu: "лет", wantRuDay: "дней", wantEn: "years"},
+		{n: 20, wantRu: "лет", wantRuDay: "дней", wantEn: "years"},
+		{n: 21, wantRu: "год", wantRuDay: "день", wantEn: "years"},
+		{n: 22, wantRu: "года", wantRuDay: "дня", wantEn: "years"},
+		{n: 25, wantRu: "лет", wantRuDay: "дней", wantEn: "years"},
+		{n: 101, wantRu: "год", wantRuDay: "день", wantEn: "years"},
+		{n: 111, wantRu: "лет", wantRuDay: "дней", wantEn: "years"},
+		{n: 112, wantRu: "лет", wantRuDay: "дней", wantEn: "years"},
+		{n: 122, wantRu: "года", wantRuDay: "дня", wantEn: "years"},
+		{n: -1, wantRu: "год", wantRuDay: "день", wantEn: "year"},
+	}
+	for _, tt := range tests {
+		if got := ru.Years(tt.n); got != tt.wantRu {
+			t.Errorf("ru: %d %s, ожидалось %s", tt.n, got, tt.wantRu)
+		}
+		if got := ru.Days(tt.n); got != tt.wantRuDay {
+			t.Errorf("ru: %d %s, ожидалось %s", tt.n, got, tt.wantRuDay)
+		}
+		if got := en.Years(tt.n); got != tt.wantEn {
+			t.Errorf("en: %d %s, ожидалось %s", tt.n, got, tt.wantEn)
+		}
+	}
+}
+
+// pluralForms категории множественного числа, которые различает каждый язык
+var pluralForms = map[Lang][]string{
+	Russian: {"one", "few", "many"},
+	English: {"one", "other"},
+}
+
+// verbPattern находит подстановки fmt в тексте каталога, в том числе с номером аргумента: %[2]s
+var verbPattern = regexp.MustCompile(`%(?:\[(\d+)\])?[-+# 0-9.]*([a-zA-Z%])`)
+
+// argVerbs возвращает подстановки текста по порядку аргументов: в переводе аргументы
+// могут стоять в другом порядке, но каждый выводится так же
+func argVerbs(text string) []string {
+	var result []string
+	next := 0
+	for _, m := range verbPattern.FindAllStringSubmatch(text, -1) {
+		if m[2] == "%" {
+			continue
+		}
+		if m[1] != "" {
+			next, _ = strconv.Atoi(m[1])
+			next--
+		}
+		for len(result) <= next {
+			result = append(result, "")
+		}
+		result[next] = m[2]
+		next++
+	}
+	return result
+}
+
+func TestCatalogs(t *testing.T) {
+	for _, lang := range Languages() {
+		t.Run(string(lang), func(t *testing.T) {
+			catalog := catalogs[lang]
+
+			// Каждый текст русского каталога переведен с теми же подстановками, лишних ключей нет
+			for key, text := range catalogs[Default] {
+				if strings.HasPrefix(key, "plural.") {
+					continue
+				}
+				translated, ok := catalog[key]
+				if !ok {
+					t.Errorf("нет ключа %s", key)
+					continue
+				}
+				if got, want := argVerbs(translated), argVerbs(text); !slices.Equal(got, want) {
+					t.Errorf("%s: подстановки %v, ожидались %v", key, got, want)
+				}
+			}
+			for key := range catalog {
+				if _, ok := catalogs[Default][key]; !ok && !strings.HasPrefix(key, "plural.") {
+					t.Errorf("лишний ключ %s", key)
+				}
+			}
+
+			// Склоняемые слова заданы во всех формах языка и только в них
+			for _, word := range []string{"plural.days", "plural.years"} {
+				for _, form := range []string{"one", "few", "many", "other"} {
+					_, ok := catalog[word+"."+form]
+					if want := slices.Contains(pluralForms[lang], form); ok != want {
+						t.Errorf("%s.%s: есть в каталоге %v, ожидалось %v", word, form, ok, want)
+					}
+				}
+			}
+		})
+	}
+}
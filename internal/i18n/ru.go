@@ -0,0 +1,213 @@
+package i18n
+
+// ru русский каталог текстов
+var ru = map[string]string{
+	// Склонения и даты
+	"plural.days.one":   "день",
+	"plural.days.few":   "дня",
+	"plural.days.many":  "дней",
+	"plural.years.one":  "год",
+	"plural.years.few":  "года",
+	"plural.years.many": "лет",
+	"month.1":           "января",
+	"month.2":           "февраля",
+	"month.3":           "марта",
+	"month.4":           "апреля",
+	"month.5":           "мая",
+	"month.6":           "июня",
+	"month.7":           "июля",
+	"month.8":           "августа",
+	"month.9":           "сентября",
+	"month.10":          "октября",
+	"month.11":          "ноября",
+	"month.12":          "декабря",
+	"date.day_month":    "%d %s",
+	"date.hint":         "ДД.ММ.ГГГГ или ДД.ММ, если год неизвестен",
+
+	// Главное меню и общие кнопки
+	"start.greeting":         "Привет! Я бот для отслеживания дней рождения. Упомяните меня в группе, чтобы начать работу.",
+	"menu.prompt":            "Выберите действие:",
+	"menu.show":              "📅 Показать дни рождения",
+	"menu.add":               "➕ Добавить день рождения",
+	"menu.edit":              "✏️ Изменить день рождения",
+	"menu.delete":            "❌ Удалить день рождения",
+	"nav.prev":               "« Назад",
+	"nav.next":               "Вперед »",
+	"button.save":            "✅ Сохранить",
+	"button.cancel":          "✖️ Отмена",
+	"callback.outdated":      "Кнопка устарела",
+	"callback.outdated_menu": "⌛ Эта кнопка устарела. Выберите действие:",
+	"access.denied":          "⛔ Изменять дни рождения и настройки могут только администраторы группы и назначенные редакторы.",
+	"answer.yes_no":          "Ответьте «да» или «нет».",
+	"answer.text_only":       "Ответьте текстовым сообщением.",
+	"conversation.cancel":    "Для отмены отправьте /cancel",
+	"conversation.none":      "Нет активного действия для отмены.",
+	"conversation.cancelled": "✖️ Действие отменено.",
+	"conversation.anonymous": "🕶 Бот не различает анонимных администраторов, поэтому не может вести с ними пошаговый диалог. Отключите анонимность в настройках администратора группы и повторите команду.",
+	"help.text": `Доступные команды:
+/start - Показать главное меню
+/list - Показать список дней рождения
+/add - Добавить день рождения
+/edit - Изменить день рождения
+/delete - Удалить день рождения
+/cancel - Отменить текущее действие
+/remind - Напомнить о днях рождения
+/timezone - Показать или изменить часовой пояс группы
+/reminders - Показать или изменить сроки напоминаний
+/editors - Показать редакторов; /editors add|remove в ответ на сообщение пользователя или с его ID
+/template - Показать и изменить шаблоны уведомлений
+/greetings - Показать и пополнить библиотеку поздравлений
+/language - Показать или изменить язык бота в группе
+/help - Показать это сообщение
+
+Изменять дни рождения и настройки могут администраторы группы и назначенные редакторы.
+Также вы можете упомянуть бота (@username) для вызова меню.`,
+
+	// Список дней рождения
+	"list.empty":    "📝 В этой группе пока нет дней рождения.",
+	"list.header":   "📅 Дни рождения в группе:",
+	"list.today":    "🎉 %s - СЕГОДНЯ! (%s)",
+	"list.upcoming": "🎂 %s - %d %s (%s)",
+	"list.error":    "❌ Ошибка при получении дней рождения: %s",
+
+	// Добавление
+	"add.prompt":      "Введите имя и фамилию.\nМожно сразу с датой рождения: Имя Фамилия ДД.ММ.ГГГГ\nЕсли год неизвестен, укажите только день и месяц: Имя Фамилия ДД.ММ",
+	"add.date_prompt": "Введите дату рождения %s в формате %s:",
+	"add.bad_date":    "Неверный формат даты. Используйте: %s",
+	"add.error":       "Ошибка при добавлении дня рождения: %s",
+	"add.done":        "✅ День рождения %s успешно добавлен!",
+
+	// Удаление
+	"delete.keyboard":      "🗑 Выберите день рождения для удаления из списка:",
+	"delete.button":        "❌ %s (%s)",
+	"delete.manual":        "⌨️ Ввести имя вручную",
+	"delete.name_prompt":   "Введите имя и фамилию человека, чей день рождения нужно удалить:",
+	"delete.name_notfound": "❌ День рождения не найден. Проверьте правильность имени и фамилии.",
+	"delete.confirm":       "Удалить день рождения %s (%s)? Ответьте «да» или «нет».",
+	"delete.cancelled":     "✖️ Удаление отменено.",
+	"delete.not_found":     "❌ День рождения не найден",
+	"delete.error":         "❌ Ошибка при удалении дня рождения: %s",
+	"delete.done":          "✅ День рождения %s успешно удален!",
+
+	// Изменение
+	"edit.keyboard":     "✏️ Выберите день рождения для изменения:",
+	"edit.button":       "✏️ %s (%s)",
+	"edit.choose_field": "Что изменить у %s (%s)?",
+	"edit.field_name":   "👤 Имя",
+	"edit.field_date":   "📅 Дату",
+	"edit.name_prompt":  "Текущее имя: %s\nВведите новое имя:",
+	"edit.date_prompt":  "Текущая дата рождения %s: %s\nВведите новую дату в формате %s:",
+	"edit.retry":        "❌ %s. Попробуйте еще раз.",
+	"edit.confirm":      "Сохранить изменения?\nБыло: %s\nСтанет: %s (%s)",
+	"edit.expired":      "⌛ Изменение не найдено или время ожидания истекло. Начните заново: /edit",
+	"edit.cancelled":    "✖️ Изменение отменено.",
+	"edit.error":        "❌ Ошибка при изменении дня рождения: %s",
+	"edit.done":         "✅ День рождения %s (%s) сохранен!",
+
+	// Часовой пояс
+	"timezone.current":    "🕰 Часовой пояс группы: %s\n\nЧтобы изменить, отправьте: /timezone Europe/Moscow",
+	"timezone.unknown":    "❌ Неизвестный часовой пояс: %s\nИспользуйте название из базы IANA, например Europe/Moscow или Asia/Yekaterinburg",
+	"timezone.save_error": "❌ Ошибка при сохранении часового пояса: %s",
+	"timezone.done":       "✅ Часовой пояс группы изменен на %s",
+
+	// Напоминания
+	"reminders.current": "🔔 Напоминания: %s\n\n" +
+		"Чтобы изменить, перечислите количество дней до дня рождения: /reminders 14 7 1 0\n" +
+		"Чтобы вернуть настройки по умолчанию: /reminders reset",
+	"reminders.bad_days":   "❌ Неверное количество дней: %s",
+	"reminders.save_error": "❌ Ошибка при сохранении напоминаний: %s",
+	"reminders.done":       "✅ Напоминания: %s",
+	"reminders.on_day":     "в день рождения",
+	"reminders.before":     "за %d %s",
+
+	// Редакторы
+	"editors.none":        "👥 Дополнительных редакторов нет. Изменять данные могут только администраторы группы.",
+	"editors.header":      "👥 Редакторы группы (кроме администраторов):",
+	"editors.admins_only": "⛔ Управлять редакторами могут только администраторы группы.",
+	"editors.usage":       "Использование: /editors add|remove в ответ на сообщение пользователя или /editors add|remove <ID пользователя>",
+	"editors.added":       "✅ %s теперь может изменять дни рождения.",
+	"editors.removed":     "✅ %s больше не может изменять дни рождения.",
+	"editors.error":       "❌ Ошибка при изменении списка редакторов: %s",
+
+	// Шаблоны уведомлений
+	"template.usage": `Шаблоны уведомлений: birthday — поздравление, jubilee — поздравление с юбилеем, reminder — напоминание.
+
+/template show <вид> - показать шаблон
+/template preview <вид> - показать пример уведомления
+/template set <вид> <текст> - задать шаблон (текст можно отправить и ответом на сообщение)
+/template reset <вид> - вернуть шаблон по умолчанию
+
+В шаблоне доступны поля: {{.Name}} - имя, {{.Age}} - возраст (0, если год неизвестен),
+{{.DaysUntil}} - дней до дня рождения, {{.When}} - срок словами, {{.Date}} - дата, {{.Jubilee}} - юбилей ли это,
+{{.Greeting}} - поздравление из библиотеки группы (/greetings).
+Склонения: {{years .Age}}, {{days .DaysUntil}}. Условия: {{if .Age}}...{{end}}.`,
+	"template.list_header": "📝 Шаблоны уведомлений группы:",
+	"template.default":     "по умолчанию",
+	"template.custom":      "свой",
+	"template.reset_error": "❌ Ошибка при сбросе шаблона: %s",
+	"template.reset_done":  "✅ Для %s снова используется шаблон по умолчанию.",
+	"template.need_text":   "Укажите текст шаблона: /template set <вид> <текст>",
+	"template.invalid":     "❌ Шаблон не сохранен: %s",
+	"template.save_error":  "❌ Ошибка при сохранении шаблона: %s",
+	"template.preview":     "👀 Пример уведомления %s:\n\n%s",
+
+	// Шаблоны уведомлений по умолчанию
+	"template.birthday": "🎉 С Днем Рождения, {{.Name}}!{{if .Age}} Сегодня тебе исполняется {{.Age}} {{years .Age}}!{{end}} 🎉" +
+		"{{with .Greeting}}\n\n{{.}}{{end}}",
+	"template.jubilee": "🎊 С юбилеем, {{.Name}}! Сегодня тебе исполняется {{.Age}} {{years .Age}}! 🎊\n\n" +
+		"Круглая дата — особый повод оглянуться на пройденный путь и загадать самое заветное.{{with .Greeting}} {{.}}{{end}}",
+	"template.reminder": "📅 {{.When}} день рождения у {{.Name}} ({{.Date}})" +
+		"{{if .Age}}, исполнится {{.Age}} {{years .Age}}{{end}}{{if .Jubilee}} — юбилей! 🎊{{end}}",
+	"template.sample_name": "Анна Иванова",
+	"template.sample_greeting": "Пусть этот день будет особенным и запомнится только радостными моментами! " +
+		"Желаем тебе счастья, успехов во всех начинаниях и исполнения всех желаний! " +
+		"Пусть каждый день приносит радость и улыбку! 🌟",
+
+	// Срок до дня рождения в уведомлениях
+	"when.today":     "Сегодня",
+	"when.tomorrow":  "Завтра",
+	"when.day_after": "Послезавтра",
+	"when.week":      "Через неделю",
+	"when.two_weeks": "Через две недели",
+	"when.days":      "Через %d %s",
+
+	// Библиотека поздравлений
+	"greetings.usage": `/greetings - показать библиотеку поздравлений
+/greetings add <текст> - добавить поздравление; в ответ на стикер или GIF они будут отправлены вместе с текстом
+/greetings remove <номер> - удалить поздравление группы`,
+	"greetings.header":       "💌 Поздравления группы. В день рождения бот выбирает одно случайно, не повторяя последние:",
+	"greetings.sticker":      " + стикер",
+	"greetings.animation":    " + GIF",
+	"greetings.builtin":      " (встроенное)",
+	"greetings.added":        "✅ Поздравление №%d добавлено.",
+	"greetings.add_error":    "❌ Ошибка при добавлении поздравления: %s",
+	"greetings.removed":      "✅ Поздравление №%d удалено.",
+	"greetings.remove_error": "❌ Ошибка при удалении поздравления: %s. Встроенные поздравления удалить нельзя.",
+
+	// Язык
+	"language.name":    "русский",
+	"language.current": "🌐 Язык бота в группе: %s\n\nЧтобы изменить, отправьте: /language en\nДоступные языки: %s",
+	"language.unknown": "❌ Неизвестный язык: %s. Доступные языки: %s",
+	"language.error":   "❌ Ошибка при сохранении языка: %s",
+	"language.done":    "✅ Язык бота в группе: %s",
+
+	// Ошибки проверки данных
+	"error.empty_name":        "имя не может быть пустым",
+	"error.empty_birthday":    "дата рождения не может быть пустой",
+	"error.future_birthday":   "дата рождения не может быть в будущем",
+	"error.birthday_too_old":  "дата рождения слишком старая",
+	"error.birthday_notfound": "день рождения не найден",
+	"error.birthday_limit":    "превышен лимит дней рождения в группе",
+	"error.too_many_reminder": "слишком много напоминаний",
+	"error.reminder_range":    "срок напоминания должен быть от 0 до 365 дней",
+	"error.editor_notfound":   "редактор не найден",
+	"error.greeting_notfound": "поздравление не найдено",
+	"error.greeting_limit":    "превышен лимит поздравлений в группе",
+	"error.greeting_empty":    "поздравление не может быть пустым",
+	"error.template_syntax":   "ошибка в записи шаблона: проверьте фигурные скобки и названия полей",
+	"error.template_execute":  "шаблон не удалось заполнить: проверьте названия полей и функций",
+	"error.template_empty":    "по шаблону получается пустой текст",
+	"error.template_too_long": "шаблон или текст по нему не помещается в одно сообщение Telegram",
+	"error.template_range":    "циклы range в шаблонах не поддерживаются",
+	"error.internal":          "внутренняя ошибка, попробуйте позже",
+}
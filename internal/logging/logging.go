@@ -0,0 +1,70 @@
+// Package logging создает структурированный журнал бота на log/slog
+// и передает через контекст журнал с атрибутами текущего обновления.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Форматы журнала
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New создает журнал, пишущий в w записи не ниже level в формате FormatText или FormatJSON
+func New(w io.Writer, level slog.Level, format string) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+
+	switch format {
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("неизвестный формат журнала %q, допустимо %s или %s", format, FormatText, FormatJSON)
+	}
+}
+
+// ParseLevel разбирает уровень журнала: debug, info, warn или error
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("неизвестный уровень журнала %q, допустимо debug, info, warn или error", s)
+	}
+	return level, nil
+}
+
+// Discard журнал, который ничего не записывает
+func Discard() *slog.Logger {
+	return slog.New(slog.DiscardHandler)
+}
+
+// ctxKey ключ журнала в контексте
+type ctxKey struct{}
+
+// WithLogger возвращает контекст с журналом logger
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, logger)
+}
+
+// FromContext возвращает журнал из контекста, а если его там нет — fallback
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// NewCorrelationID создает идентификатор, по которому в журнале находятся все записи одного обновления
+func NewCorrelationID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b) // crypto/rand.Read не возвращает ошибок
+	return hex.EncodeToString(b)
+}
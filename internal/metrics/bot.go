@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Метрики бота
+var (
+	// UpdatesHandled обработанные обновления по типу (command, message, callback, member, other) и результату (ok, error)
+	UpdatesHandled = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "birthday_bot_updates_total",
+		Help: "Обработанные обновления Telegram.",
+	}, []string{"kind", "status"})
+
+	// Commands полученные команды по имени без косой черты
+	Commands = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "birthday_bot_commands_total",
+		Help: "Полученные команды бота.",
+	}, []string{"command"})
+
+	// Notifications уведомления о днях рождения по группе и результату (sent, failed).
+	// Ряды групп, в которые бот больше не пишет, удаляет ForgetGroup.
+	Notifications = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "birthday_bot_notifications_total",
+		Help: "Уведомления о днях рождения, отправленные в группы.",
+	}, []string{"group_id", "status"})
+
+	// UpdateQueue обновления, ожидающие обработки, по номеру обработчика
+	UpdateQueue = factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "birthday_bot_update_queue_depth",
+		Help: "Обновления в очереди обработчика.",
+	}, []string{"worker"})
+
+	// UpdateQueueFull сколько раз очередь обработчика была заполнена и прием обновлений ждал
+	UpdateQueueFull = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "birthday_bot_update_queue_full_total",
+		Help: "Ожидания из-за заполненной очереди обработчика.",
+	}, []string{"worker"})
+
+	// TelegramRequests запросы к Bot API через очередь отправки по методу и результату (ok, retried, failed)
+	TelegramRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "birthday_bot_telegram_requests_total",
+		Help: "Запросы к Bot API через очередь отправки.",
+	}, []string{"method", "status"})
+
+	// TelegramWait ожидание очереди отправки из-за ограничений частоты Telegram
+	TelegramWait = factory.NewHistogram(prometheus.HistogramOpts{
+		Name:    "birthday_bot_telegram_send_wait_seconds",
+		Help:    "Ожидание очереди отправки из-за ограничений частоты.",
+		Buckets: DefaultBuckets,
+	})
+
+	// SchedulerTick длительность одной проверки дней рождения планировщиком
+	SchedulerTick = factory.NewHistogram(prometheus.HistogramOpts{
+		Name:    "birthday_bot_scheduler_tick_duration_seconds",
+		Help:    "Длительность проверки дней рождения планировщиком.",
+		Buckets: DefaultBuckets,
+	})
+
+	// DBQuery длительность запросов к базе данных по виду запроса (exec, query)
+	DBQuery = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "birthday_bot_db_query_duration_seconds",
+		Help:    "Длительность запросов к базе данных.",
+		Buckets: DefaultBuckets,
+	}, []string{"op"})
+)
+
+// ForgetGroup удаляет ряды группы, которая отключена или стала супергруппой,
+// чтобы число рядов не превышало числа групп, в которые пишет бот
+func ForgetGroup(groupID int64) {
+	Notifications.DeletePartialMatch(prometheus.Labels{"group_id": strconv.FormatInt(groupID, 10)})
+}
@@ -0,0 +1,35 @@
+// Package metrics собирает метрики бота и отдает их в текстовом формате Prometheus.
+//
+// Метрики регистрируются в собственном реестре пакета вместе со стандартными метриками
+// среды выполнения Go и процесса, Handler выводит их все. Метрики бота объявлены в bot.go.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultBuckets границы гистограмм длительности в секундах: от миллисекунды до десяти секунд
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// registry реестр метрик бота
+var registry = prometheus.NewRegistry()
+
+// factory создает метрики, сразу регистрируя их в registry
+var factory = promauto.With(registry)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler отдает все метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// scrape запрашивает Handler и разбирает ответ парсером текстового формата Prometheus
+func scrape(t *testing.T) map[string]*dto.MetricFamily {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("Content-Type %q", ct)
+	}
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(rec.Body)
+	if err != nil {
+		t.Fatalf("вывод метрик не разобран: %v", err)
+	}
+	return families
+}
+
+// labelNames возвращает имена меток ряда
+func labelNames(m *dto.Metric) []string {
+	var names []string
+	for _, l := range m.GetLabel() {
+		names = append(names, l.GetName())
+	}
+	return names
+}
+
+// notificationSeries возвращает ряды уведомлений в виде "группа/результат=значение"
+func notificationSeries(families map[string]*dto.MetricFamily) []string {
+	var series []string
+	for _, m := range families["birthday_bot_notifications_total"].GetMetric() {
+		labels := m.GetLabel()
+		series = append(series, fmt.Sprintf("%s/%s=%v", labels[0].GetValue(), labels[1].GetValue(), m.GetCounter().GetValue()))
+	}
+	return series
+}
+
+func TestHandler(t *testing.T) {
+	UpdatesHandled.WithLabelValues("command", "ok").Inc()
+	Commands.WithLabelValues("list").Add(2)
+	Notifications.WithLabelValues("-1001", "sent").Add(3)
+	Notifications.WithLabelValues("-1002", "failed").Inc()
+	UpdateQueue.WithLabelValues("0").Set(5)
+	UpdateQueueFull.WithLabelValues("0").Inc()
+	TelegramRequests.WithLabelValues("sendMessage", "retried").Inc()
+	TelegramWait.Observe(0.3)
+	SchedulerTick.Observe(0.002)
+	DBQuery.WithLabelValues("query").Observe(0.05)
+
+	families := scrape(t)
+
+	tests := []struct {
+		name   string
+		typ    dto.MetricType
+		labels []string
+	}{
+		{name: "birthday_bot_updates_total", typ: dto.MetricType_COUNTER, labels: []string{"kind", "status"}},
+		{name: "birthday_bot_commands_total", typ: dto.MetricType_COUNTER, labels: []string{"command"}},
+		{name: "birthday_bot_notifications_total", typ: dto.MetricType_COUNTER, labels: []string{"group_id", "status"}},
+		{name: "birthday_bot_update_queue_depth", typ: dto.MetricType_GAUGE, labels: []string{"worker"}},
+		{name: "birthday_bot_update_queue_full_total", typ: dto.MetricType_COUNTER, labels: []string{"worker"}},
+		{name: "birthday_bot_telegram_requests_total", typ: dto.MetricType_COUNTER, labels: []string{"method", "status"}},
+		{name: "birthday_bot_telegram_send_wait_seconds", typ: dto.MetricType_HISTOGRAM},
+		{name: "birthday_bot_scheduler_tick_duration_seconds", typ: dto.MetricType_HISTOGRAM},
+		{name: "birthday_bot_db_query_duration_seconds", typ: dto.MetricType_HISTOGRAM, labels: []string{"op"}},
+		{name: "go_goroutines", typ: dto.MetricType_GAUGE},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			family, ok := families[tt.name]
+			if !ok {
+				t.Fatal("метрики нет в выводе")
+			}
+			if family.GetType() != tt.typ {
+				t.Fatalf("тип %v, ожидался %v", family.GetType(), tt.typ)
+			}
+			for _, m := range family.GetMetric() {
+				if got := labelNames(m); !slices.Equal(got, tt.labels) {
+					t.Fatalf("метки %v, ожидались %v", got, tt.labels)
+				}
+			}
+		})
+	}
+
+	if got := notificationSeries(families); !slices.Equal(got, []string{"-1001/sent=3", "-1002/failed=1"}) {
+		t.Errorf("ряды уведомлений %v", got)
+	}
+
+	// Ряды отключенной группы удаляются, остальные остаются
+	ForgetGroup(-1002)
+	if got := notificationSeries(scrape(t)); !slices.Equal(got, []string{"-1001/sent=3"}) {
+		t.Errorf("ряды уведомлений после отключения группы %v", got)
+	}
+
+	wait := families["birthday_bot_telegram_send_wait_seconds"].GetMetric()[0].GetHistogram()
+	if wait.GetSampleCount() != 1 || wait.GetSampleSum() != 0.3 {
+		t.Errorf("гистограмма ожидания: count %d, sum %v", wait.GetSampleCount(), wait.GetSampleSum())
+	}
+	for _, b := range wait.GetBucket() {
+		want := uint64(0)
+		if b.GetUpperBound() >= 0.3 {
+			want = 1
+		}
+		if b.GetCumulativeCount() != want {
+			t.Errorf("корзина le=%v: %d, ожидалось %d", b.GetUpperBound(), b.GetCumulativeCount(), want)
+		}
+	}
+}
@@ -1,16 +1,32 @@
 package models
 
 import (
-	"fmt"
+	"errors"
 	"time"
 )
 
+// Ошибки проверки записей. Обработчики сравнивают с ними через errors.Is,
+// чтобы показать пользователю текст на языке группы.
+var (
+	ErrEmptyName       = errors.New("имя не может быть пустым")
+	ErrEmptyBirthday   = errors.New("дата рождения не может быть пустой")
+	ErrFutureBirthday  = errors.New("дата рождения не может быть в будущем")
+	ErrBirthdayTooOld  = errors.New("дата рождения слишком старая")
+	ErrEmptyGroupID    = errors.New("ID группы не может быть пустым")
+	ErrEmptyGroupTitle = errors.New("название группы не может быть пустым")
+)
+
+// UnknownYear год, который подставляется в дату рождения, если настоящий год неизвестен.
+// Високосный, чтобы можно было сохранить 29 февраля.
+const UnknownYear = 2000
+
 // Birthday представляет запись о дне рождения
 type Birthday struct {
-	ID       int64     `json:"id"`
-	Name     string    `json:"name"`
-	Birthday time.Time `json:"birthday"`
-	GroupID  int64     `json:"group_id"`
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Birthday    time.Time `json:"birthday"`
+	YearUnknown bool      `json:"year_unknown"` // Известны только день и месяц, год в Birthday равен UnknownYear
+	GroupID     int64     `json:"group_id"`
 }
 
 // Group представляет группу в Telegram
@@ -19,37 +35,89 @@ type Group struct {
 	Title string `json:"title"`
 }
 
-// Validate проверяет валидность записи о дне рождения
-func (b *Birthday) Validate() error {
+// Editor представляет пользователя, которому администраторы группы
+// разрешили изменять дни рождения и настройки
+type Editor struct {
+	GroupID int64  `json:"group_id"`
+	UserID  int64  `json:"user_id"`
+	Name    string `json:"name"`
+}
+
+// Типы вложений поздравления
+const (
+	MediaSticker   = "sticker"   // Стикер
+	MediaAnimation = "animation" // GIF-анимация
+)
+
+// Greeting представляет поздравление из библиотеки.
+// Встроенные поздравления доступны всем группам и хранятся с GroupID, равным 0.
+type Greeting struct {
+	ID        int64  `json:"id"`
+	GroupID   int64  `json:"group_id"`
+	Text      string `json:"text"`
+	MediaType string `json:"media_type"` // Тип вложения: MediaSticker, MediaAnimation или пустая строка
+	FileID    string `json:"file_id"`    // file_id вложения в Telegram
+}
+
+// Builtin проверяет, является ли поздравление встроенным
+func (g *Greeting) Builtin() bool {
+	return g.GroupID == 0
+}
+
+// Conversation хранит состояние пошагового диалога пользователя в чате
+type Conversation struct {
+	ChatID    int64             `json:"chat_id"`
+	UserID    int64             `json:"user_id"`
+	Flow      string            `json:"flow"`       // Сценарий диалога (добавление, изменение, удаление)
+	Step      string            `json:"step"`       // Текущий шаг сценария
+	Data      map[string]string `json:"data"`       // Ответы, собранные на предыдущих шагах
+	UpdatedAt time.Time         `json:"updated_at"` // Время последнего шага
+}
+
+// Validate проверяет валидность записи о дне рождения на момент now
+func (b *Birthday) Validate(now time.Time) error {
 	if b.Name == "" {
-		return fmt.Errorf("имя не может быть пустым")
+		return ErrEmptyName
 	}
 
 	if b.Birthday.IsZero() {
-		return fmt.Errorf("дата рождения не может быть пустой")
+		return ErrEmptyBirthday
+	}
+
+	// Без года проверять возраст нечего
+	if b.YearUnknown {
+		return nil
 	}
 
 	// Проверяем, что дата рождения не в будущем
-	if b.Birthday.After(time.Now()) {
-		return fmt.Errorf("дата рождения не может быть в будущем")
+	if b.Birthday.After(now) {
+		return ErrFutureBirthday
 	}
 
 	// Проверяем, что дата рождения не слишком старая (например, не старше 150 лет)
-	if time.Now().Sub(b.Birthday) > 150*365*24*time.Hour {
-		return fmt.Errorf("дата рождения слишком старая")
+	if now.Sub(b.Birthday) > 150*365*24*time.Hour {
+		return ErrBirthdayTooOld
 	}
 
 	return nil
 }
 
+// FormatDate возвращает дату рождения в формате ДД.ММ.ГГГГ или ДД.ММ, если год неизвестен
+func (b *Birthday) FormatDate() string {
+	if b.YearUnknown {
+		return b.Birthday.Format("02.01")
+	}
+	return b.Birthday.Format("02.01.2006")
+}
+
 // Validate проверяет валидность записи о группе
 func (g *Group) Validate() error {
 	if g.ID == 0 {
-		return fmt.Errorf("ID группы не может быть пустым")
+		return ErrEmptyGroupID
 	}
 
 	if g.Title == "" {
-		return fmt.Errorf("название группы не может быть пустым")
+		return ErrEmptyGroupTitle
 	}
 
 	return nil
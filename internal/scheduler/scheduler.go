@@ -2,53 +2,105 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"strings"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strconv"
 	"time"
 
+	"Eldarius_bot/internal/calendar"
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/i18n"
+	"Eldarius_bot/internal/logging"
+	"Eldarius_bot/internal/metrics"
 	"Eldarius_bot/internal/models"
 	"Eldarius_bot/internal/storage"
+	"Eldarius_bot/internal/telegram"
+	"Eldarius_bot/internal/templates"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 const (
-	// Number of days to notify in advance
-	advanceNotificationDays = 3
+	// jubileeHeadsUpDays за сколько дней до юбилея отправляется дополнительное напоминание,
+	// чтобы успеть подготовиться к празднику
+	jubileeHeadsUpDays = 30
+	// failureBackoff и maxFailureBackoff пауза перед следующей попыткой отправки в группу,
+	// в которую Telegram окончательно отказался доставить уведомление: удваивается
+	// с каждой неудачей, но не превышает maxFailureBackoff
+	failureBackoff    = 15 * time.Minute
+	maxFailureBackoff = 6 * time.Hour
 )
 
 // Scheduler планирует и отправляет уведомления о днях рождения
 type Scheduler struct {
-	store storage.Repository
-	bot   *tgbotapi.BotAPI
+	store  storage.Repository
+	bot    telegram.Messenger
+	clock  clock.Clock
+	logger *slog.Logger
+
+	// postponed группы, отправка в которые отложена после окончательной ошибки Telegram.
+	// Используется только из горутины, в которой идут проверки.
+	postponed map[int64]postponement
+}
+
+// postponement отложенная отправка в группу
+type postponement struct {
+	until time.Time     // Раньше этого времени в группу не пишем
+	delay time.Duration // Пауза, с которой отправка отложена в последний раз
 }
 
 // NewScheduler создает новый планировщик уведомлений
-func NewScheduler(store storage.Repository, bot *tgbotapi.BotAPI) *Scheduler {
+func NewScheduler(store storage.Repository, bot telegram.Messenger, clk clock.Clock, logger *slog.Logger) *Scheduler {
 	return &Scheduler{
-		store: store,
-		bot:   bot,
+		store:  store,
+		bot:    bot,
+		clock:  clk,
+		logger: logger,
+
+		postponed: make(map[int64]postponement),
 	}
 }
 
-// Start запускает планировщик уведомлений
+// log возвращает журнал текущей проверки с ее correlation_id и группой, если она известна
+func (s *Scheduler) log(ctx context.Context) *slog.Logger {
+	return logging.FromContext(ctx, s.logger)
+}
+
+// Start запускает планировщик уведомлений и работает до отмены ctx.
+// Отмена не прерывает рассылку в группу, которая уже началась.
 func (s *Scheduler) Start(ctx context.Context) error {
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
 
+	// Сразу после запуска досылаем уведомления, пропущенные во время простоя
+	s.tick(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			if err := s.checkBirthdays(ctx); err != nil {
-				// Логируем ошибку, но продолжаем работу
-				fmt.Printf("Ошибка при проверке дней рождения: %v\n", err)
-			}
+			s.tick(ctx)
 		}
 	}
 }
 
+// tick проверяет дни рождения и записывает длительность проверки в метрики.
+// Все записи журнала одной проверки получают общий correlation_id.
+func (s *Scheduler) tick(ctx context.Context) {
+	start := time.Now()
+	defer func() { metrics.SchedulerTick.Observe(time.Since(start).Seconds()) }()
+
+	ctx = logging.WithLogger(ctx, s.logger.With(slog.String("correlation_id", logging.NewCorrelationID())))
+	if err := s.checkBirthdays(ctx); err != nil {
+		// Логируем ошибку, но продолжаем работу
+		s.log(ctx).Error("Ошибка проверки дней рождения", slog.Any("error", err))
+	}
+}
+
 // checkBirthdays проверяет дни рождения и отправляет уведомления
 func (s *Scheduler) checkBirthdays(ctx context.Context) error {
 	// Получаем все группы
@@ -59,88 +111,356 @@ func (s *Scheduler) checkBirthdays(ctx context.Context) error {
 
 	// Проверяем дни рождения для каждой группы
 	for _, group := range groups {
-		// Получаем время уведомления для группы
-		notifyTime, err := s.store.GetNotifyTime(ctx, group.ID)
+		// При остановке не переходим к следующей группе: пропущенное досылается после запуска
+		if ctx.Err() != nil {
+			return nil
+		}
+		// Отправка в группу отложена после окончательной ошибки
+		if s.clock.Now().Before(s.postponed[group.ID].until) {
+			continue
+		}
+
+		// Начатую группу обрабатываем до конца, чтобы отправленное уведомление попало в журнал
+		ctx := logging.WithLogger(context.WithoutCancel(ctx), s.log(ctx).With(slog.Int64("group_id", group.ID)))
+
+		groupID := group.ID
+		err := s.checkGroup(ctx, groupID)
+
+		// Группа стала супергруппой, а сообщение о переносе бот пропустил:
+		// переносим данные по ответу Telegram и отправляем уведомления в супергруппу
+		if newID := telegram.MigratedTo(err); newID != 0 {
+			if err = s.store.MigrateGroup(ctx, groupID, newID); err == nil {
+				s.log(ctx).Info("Группа стала супергруппой, данные перенесены", slog.Int64("new_group_id", newID))
+				metrics.ForgetGroup(groupID)
+				delete(s.postponed, groupID)
+				groupID = newID
+				ctx = logging.WithLogger(ctx, s.log(ctx).With(slog.Int64("new_group_id", newID)))
+				err = s.checkGroup(ctx, groupID)
+			}
+		}
+
 		if err != nil {
-			fmt.Printf("Ошибка получения времени уведомления для группы %d: %v\n", group.ID, err)
+			s.log(ctx).Error("Ошибка отправки уведомления", telegram.ErrorAttrs(err)...)
+			s.handleSendFailure(ctx, groupID, err)
 			continue
 		}
+		delete(s.postponed, groupID)
+	}
 
-		// Проверяем, нужно ли отправлять уведомление
-		if !s.shouldNotify(notifyTime) {
+	return nil
+}
+
+// checkGroup отправляет в группу уведомления, которые положены сейчас.
+// Возвращает только ошибку отправки: остальные ошибки записываются в журнал,
+// и группа проверяется снова при следующей проверке.
+func (s *Scheduler) checkGroup(ctx context.Context, groupID int64) error {
+	// Получаем время уведомления для группы
+	notifyTime, err := s.store.GetNotifyTime(ctx, groupID)
+	if err != nil {
+		s.log(ctx).Warn("Ошибка получения времени уведомления", slog.Any("error", err))
+		return nil
+	}
+
+	// Получаем часовой пояс группы
+	loc, err := s.store.GetTimezone(ctx, groupID)
+	if err != nil {
+		s.log(ctx).Warn("Ошибка получения часового пояса", slog.Any("error", err))
+		return nil
+	}
+	now := s.clock.Now().In(loc)
+
+	// Проверяем, нужно ли отправлять уведомление
+	if !s.shouldNotify(notifyTime, now) {
+		return nil
+	}
+
+	// Получаем сроки напоминаний группы
+	offsets, err := s.store.GetReminderOffsets(ctx, groupID)
+	if err != nil {
+		s.log(ctx).Warn("Ошибка получения сроков напоминаний", slog.Any("error", err))
+		return nil
+	}
+
+	// Получаем дни рождения в пределах самого дальнего срока напоминания, включая напоминание о юбилеях
+	birthdays, err := s.store.GetUpcomingBirthdays(ctx, groupID, max(slices.Max(offsets), jubileeHeadsUpDays))
+	if err != nil {
+		s.log(ctx).Warn("Ошибка получения предстоящих дней рождения", slog.Any("error", err))
+		return nil
+	}
+
+	// Отбираем уведомления, которые сегодня еще не отправлялись
+	pending, err := s.pendingNotifications(ctx, groupID, birthdays, offsets, now)
+	if err != nil {
+		s.log(ctx).Warn("Ошибка проверки журнала уведомлений", slog.Any("error", err))
+		return nil
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	// Отправляем уведомление на языке группы
+	return s.sendGroupNotification(ctx, groupID, s.localizer(ctx, groupID), pending)
+}
+
+// handleSendFailure не дает повторять каждую минуту отправку, которую Telegram отклонил окончательно.
+// Если бот больше не может писать в группу, группа отключается до его возвращения,
+// после остальных окончательных ошибок отправка в группу откладывается с нарастающей паузой.
+// Временные ошибки повторяются при следующей проверке.
+func (s *Scheduler) handleSendFailure(ctx context.Context, groupID int64, err error) {
+	switch {
+	case telegram.IsChatUnavailable(err):
+		if err := s.store.SetGroupActive(ctx, groupID, false); err != nil {
+			s.log(ctx).Warn("Ошибка отключения группы", slog.Any("error", err))
+			return
+		}
+		metrics.ForgetGroup(groupID)
+		delete(s.postponed, groupID)
+		s.log(ctx).Warn("Бот не может писать в группу, группа отключена")
+	case telegram.IsPermanent(err):
+		p := s.postponed[groupID]
+		p.delay = min(max(p.delay*2, failureBackoff), maxFailureBackoff)
+		p.until = s.clock.Now().Add(p.delay)
+		s.postponed[groupID] = p
+		s.log(ctx).Warn("Отправка в группу отложена", slog.Time("until", p.until))
+	}
+}
+
+// shouldNotify проверяет, наступило ли сегодня время уведомления.
+// Время уведомления сравнивается с now в часовом поясе группы. Повторная отправка
+// после наступления этого времени исключается журналом уведомлений.
+func (s *Scheduler) shouldNotify(notifyTime time.Time, now time.Time) bool {
+	targetTime := time.Date(now.Year(), now.Month(), now.Day(),
+		notifyTime.Hour(), notifyTime.Minute(), 0, 0, now.Location())
+
+	return !now.Before(targetTime)
+}
+
+// notification описывает одно уведомление о дне рождения
+type notification struct {
+	birthday   *models.Birthday
+	occurrence time.Time // Дата ближайшего дня рождения
+	daysBefore int       // За сколько дней до дня рождения отправляется уведомление
+	age        int       // Сколько лет исполняется, 0 — год рождения неизвестен
+	jubilee    bool      // День рождения — юбилей
+}
+
+// pendingNotifications возвращает уведомления на сегодня, которых еще нет в журнале.
+// Уведомление положено, если до дня рождения осталось ровно столько дней,
+// сколько указано в одном из сроков напоминаний группы, а о юбилее — еще и за jubileeHeadsUpDays дней.
+func (s *Scheduler) pendingNotifications(ctx context.Context, groupID int64, birthdays []*models.Birthday, offsets []int, now time.Time) ([]notification, error) {
+	var pending []notification
+	for _, b := range birthdays {
+		o := calendar.Next(b, now)
+		if !slices.Contains(offsets, o.DaysUntil) && !(o.Jubilee() && o.DaysUntil == jubileeHeadsUpDays) {
 			continue
 		}
 
-		// Получаем предстоящие дни рождения
-		birthdays, err := s.store.GetUpcomingBirthdays(ctx, group.ID, 7)
+		age, _ := o.Age()
+		n := notification{
+			birthday:   b,
+			occurrence: o.Date,
+			daysBefore: o.DaysUntil,
+			age:        age,
+			jubilee:    o.Jubilee(),
+		}
+
+		sent, err := s.store.IsNotificationSent(ctx, groupID, b.ID, n.occurrence, n.daysBefore)
 		if err != nil {
-			fmt.Printf("Ошибка получения предстоящих дней рождения для группы %d: %v\n", group.ID, err)
+			return nil, err
+		}
+		if !sent {
+			pending = append(pending, n)
+		}
+	}
+
+	return pending, nil
+}
+
+// sendGroupNotification отправляет уведомления в группу и записывает их в журнал.
+// Каждое уведомление — отдельное сообщение по шаблону группы: от самого дальнего срока к самому близкому.
+func (s *Scheduler) sendGroupNotification(ctx context.Context, groupID int64, l i18n.Localizer, pending []notification) error {
+	slices.SortStableFunc(pending, func(a, b notification) int {
+		return b.daysBefore - a.daysBefore
+	})
+
+	var errs []error
+	for _, n := range pending {
+		kind := notificationKind(n)
+		body := s.groupTemplate(ctx, groupID, kind)
+
+		// В день рождения к поздравлению добавляется текст из библиотеки группы,
+		// если шаблон его выводит: иначе выбранное поздравление никто не увидит
+		var greeting *models.Greeting
+		if n.daysBefore == 0 && templates.UsesGreeting(l, kind, body) {
+			greeting = s.pickGreeting(ctx, groupID, l.Lang(), n.birthday.ID)
+		}
+
+		text, err := s.render(l, kind, body, n, greeting)
+		if text == "" {
+			errs = append(errs, err)
 			continue
 		}
+		if err != nil {
+			// Сломанный шаблон группы не должен останавливать уведомления: текст уже сформирован по шаблону по умолчанию
+			s.log(ctx).Warn("Ошибка шаблона уведомления", slog.Any("error", err))
+		}
 
-		if len(birthdays) == 0 {
+		if err := s.deliver(ctx, groupID, text, n); err != nil {
+			errs = append(errs, err)
+			// Остальные сообщения Telegram отклонит так же
+			if telegram.IsPermanent(err) {
+				break
+			}
 			continue
 		}
 
-		// Отправляем уведомление
-		if err := s.sendGroupNotification(ctx, group.ID, birthdays); err != nil {
-			fmt.Printf("Ошибка отправки уведомления в группу %d: %v\n", group.ID, err)
+		if greeting != nil {
+			errs = append(errs, s.finishGreeting(ctx, groupID, n.birthday.ID, greeting))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
-// shouldNotify проверяет, нужно ли отправлять уведомление
-func (s *Scheduler) shouldNotify(notifyTime time.Time) bool {
-	now := time.Now()
-	targetTime := time.Date(now.Year(), now.Month(), now.Day(),
-		notifyTime.Hour(), notifyTime.Minute(), 0, 0, time.Local)
+// greetingHistorySize сколько последних поздравлений именинника не повторяется
+const greetingHistorySize = 3
+
+// pickGreeting выбирает случайное поздравление из библиотеки группы на языке lang,
+// пропуская последние поздравления этого именинника. Возвращает nil, если библиотека пуста.
+func (s *Scheduler) pickGreeting(ctx context.Context, groupID int64, lang i18n.Lang, birthdayID int64) *models.Greeting {
+	greetings, err := s.store.GetGreetings(ctx, groupID, string(lang))
+	if err != nil {
+		s.log(ctx).Warn("Ошибка получения поздравлений", slog.Any("error", err))
+		return nil
+	}
+	if len(greetings) == 0 {
+		return nil
+	}
+
+	recent, err := s.store.GetRecentGreetings(ctx, groupID, birthdayID, greetingHistorySize)
+	if err != nil {
+		s.log(ctx).Warn("Ошибка получения истории поздравлений", slog.Any("error", err))
+	}
 
-	// Проверяем, что текущее время находится в пределах одной минуты от целевого времени
-	return now.Sub(targetTime).Abs() <= time.Minute
+	var candidates []*models.Greeting
+	for _, g := range greetings {
+		if !slices.Contains(recent, g.ID) {
+			candidates = append(candidates, g)
+		}
+	}
+	// В маленькой библиотеке повторов не избежать
+	if len(candidates) == 0 {
+		candidates = greetings
+	}
+
+	return candidates[rand.IntN(len(candidates))]
 }
 
-// sendGroupNotification отправляет уведомление в группу
-func (s *Scheduler) sendGroupNotification(ctx context.Context, groupID int64, birthdays []*models.Birthday) error {
-	var text strings.Builder
-	text.WriteString("🎂 Предстоящие дни рождения:\n\n")
+// finishGreeting отправляет вложение поздравления и записывает поздравление в историю именинника
+func (s *Scheduler) finishGreeting(ctx context.Context, groupID, birthdayID int64, greeting *models.Greeting) error {
+	var err error
+	switch greeting.MediaType {
+	case models.MediaSticker:
+		_, err = s.bot.Send(tgbotapi.NewSticker(groupID, tgbotapi.FileID(greeting.FileID)))
+	case models.MediaAnimation:
+		_, err = s.bot.Send(tgbotapi.NewAnimation(groupID, tgbotapi.FileID(greeting.FileID)))
+	}
+	if err != nil {
+		err = fmt.Errorf("ошибка отправки вложения поздравления %d: %w", greeting.ID, err)
+	}
 
-	for _, b := range birthdays {
-		nextBirthday := getNextBirthday(b.Birthday, time.Now())
-		daysUntil := int(nextBirthday.Sub(time.Now()).Hours() / 24)
+	return errors.Join(err, s.store.MarkGreetingUsed(ctx, groupID, birthdayID, greeting.ID))
+}
 
-		if daysUntil == 0 {
-			text.WriteString(fmt.Sprintf("🎉 Сегодня день рождения у %s!\n", b.Name))
-		} else {
-			text.WriteString(fmt.Sprintf("📅 Через %d %s день рождения у %s\n",
-				daysUntil,
-				getDaysWord(daysUntil),
-				b.Name))
-		}
+// notificationKind возвращает вид уведомления: поздравление, поздравление с юбилеем или напоминание
+func notificationKind(n notification) templates.Kind {
+	if n.daysBefore != 0 {
+		return templates.KindReminder
+	}
+	if n.jubilee {
+		return templates.KindJubilee
+	}
+	return templates.KindBirthday
+}
+
+// groupTemplate возвращает шаблон группы для вида уведомления kind или пустую строку для шаблона по умолчанию
+func (s *Scheduler) groupTemplate(ctx context.Context, groupID int64, kind templates.Kind) string {
+	body, err := s.store.GetTemplate(ctx, groupID, string(kind))
+	if err != nil {
+		// Без шаблона группы отправляем текст по умолчанию
+		s.log(ctx).Warn("Ошибка получения шаблона", slog.Any("error", err))
+		return ""
 	}
+	return body
+}
 
-	msg := tgbotapi.NewMessage(groupID, text.String())
-	_, err := s.bot.Send(msg)
-	return err
+// render формирует текст уведомления вида kind по шаблону группы body
+func (s *Scheduler) render(l i18n.Localizer, kind templates.Kind, body string, n notification, greeting *models.Greeting) (string, error) {
+	data := templates.Data{
+		Name:      n.birthday.Name,
+		Age:       n.age,
+		DaysUntil: n.daysBefore,
+		When:      whenText(l, n.daysBefore),
+		Date:      l.DayMonth(n.occurrence),
+		Jubilee:   n.jubilee,
+	}
+	if greeting != nil {
+		data.Greeting = greeting.Text
+	}
+
+	return templates.RenderOrDefault(l, kind, body, data)
 }
 
-// getNextBirthday вычисляет дату следующего дня рождения
-func getNextBirthday(birthday time.Time, now time.Time) time.Time {
-	nextBirthday := time.Date(now.Year(), birthday.Month(), birthday.Day(), 0, 0, 0, 0, time.Local)
-	if nextBirthday.Before(now) {
-		nextBirthday = nextBirthday.AddDate(1, 0, 0)
+// whenText описывает словами, через сколько дней наступит день рождения
+func whenText(l i18n.Localizer, days int) string {
+	switch days {
+	case 0:
+		return l.T("when.today")
+	case 1:
+		return l.T("when.tomorrow")
+	case 2:
+		return l.T("when.day_after")
+	case 7:
+		return l.T("when.week")
+	case 14:
+		return l.T("when.two_weeks")
+	default:
+		return l.T("when.days", days, l.Days(days))
 	}
-	return nextBirthday
 }
 
-// getDaysWord возвращает правильное склонение слова "день"
-func getDaysWord(days int) string {
-	if days%10 == 1 && days%100 != 11 {
-		return "день"
+// localizer возвращает тексты на языке группы. Если язык получить не удалось, используется язык по умолчанию.
+func (s *Scheduler) localizer(ctx context.Context, groupID int64) i18n.Localizer {
+	code, err := s.store.GetLanguage(ctx, groupID)
+	if err != nil {
+		s.log(ctx).Warn("Ошибка получения языка", slog.Any("error", err))
 	}
-	if days%10 >= 2 && days%10 <= 4 && (days%100 < 10 || days%100 >= 20) {
-		return "дня"
+	lang, _ := i18n.Parse(code)
+	return i18n.New(lang)
+}
+
+// deliver отправляет сообщение и отмечает связанные с ним уведомления как отправленные
+func (s *Scheduler) deliver(ctx context.Context, groupID int64, text string, notifications ...notification) error {
+	group := strconv.FormatInt(groupID, 10)
+	if err := s.send(groupID, text); err != nil {
+		metrics.Notifications.WithLabelValues(group, "failed").Add(float64(len(notifications)))
+		return err
+	}
+	metrics.Notifications.WithLabelValues(group, "sent").Add(float64(len(notifications)))
+
+	for _, n := range notifications {
+		if err := s.store.MarkNotificationSent(ctx, groupID, n.birthday.ID, n.occurrence, n.daysBefore); err != nil {
+			return err
+		}
 	}
-	return "дней"
+
+	return nil
+}
+
+// send отправляет текстовое сообщение в группу
+func (s *Scheduler) send(groupID int64, text string) error {
+	msg := tgbotapi.NewMessage(groupID, text)
+	_, err := s.bot.Send(msg)
+	return err
 }
@@ -0,0 +1,527 @@
+package scheduler
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/i18n"
+	"Eldarius_bot/internal/logging"
+	"Eldarius_bot/internal/models"
+	"Eldarius_bot/internal/storage"
+	"Eldarius_bot/internal/telegram/telegramtest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// testGroupID ID группы в тестах планировщика
+const testGroupID = -1001
+
+// newTestScheduler создает планировщик с поддельным Bot API, поддельными часами,
+// показывающими now, и временной базой, в которой есть группа с часовым поясом now
+// и уведомлениями в notifyAt (часы и минуты)
+func newTestScheduler(t *testing.T, now time.Time, notifyAt string) (*Scheduler, storage.Repository, *telegramtest.Server, *clock.Fake) {
+	t.Helper()
+
+	srv := telegramtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(telegramtest.Token, srv.Endpoint())
+	if err != nil {
+		t.Fatalf("ошибка создания бота: %v", err)
+	}
+
+	clk := clock.NewFake(now)
+	store, err := storage.NewSQLite(filepath.Join(t.TempDir(), "birthdays.db"), clk, logging.Discard())
+	if err != nil {
+		t.Fatalf("ошибка открытия хранилища: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	ctx := context.Background()
+	if err := store.EnsureGroup(ctx, &models.Group{ID: testGroupID, Title: "Друзья"}); err != nil {
+		t.Fatalf("ошибка создания группы: %v", err)
+	}
+	if err := store.SetTimezone(ctx, testGroupID, now.Location()); err != nil {
+		t.Fatalf("ошибка установки часового пояса: %v", err)
+	}
+	notifyTime, err := time.Parse("15:04", notifyAt)
+	if err != nil {
+		t.Fatalf("время уведомления %q: %v", notifyAt, err)
+	}
+	if err := store.SetNotifyTime(ctx, testGroupID, notifyTime); err != nil {
+		t.Fatalf("ошибка установки времени уведомления: %v", err)
+	}
+
+	return NewScheduler(store, bot, clk, logging.Discard()), store, srv, clk
+}
+
+// mustTime возвращает момент value ("2006-01-02 15:04") в часовом поясе tz
+func mustTime(t *testing.T, tz, value string) time.Time {
+	t.Helper()
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Fatalf("часовой пояс %s: %v", tz, err)
+	}
+	now, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	if err != nil {
+		t.Fatalf("время %q: %v", value, err)
+	}
+	return now
+}
+
+// addBirthday добавляет день рождения с датой birthday ("2006-01-02")
+func addBirthday(t *testing.T, store storage.Repository, name, birthday string) {
+	t.Helper()
+
+	date, err := time.Parse("2006-01-02", birthday)
+	if err != nil {
+		t.Fatalf("дата рождения %q: %v", birthday, err)
+	}
+	b := &models.Birthday{Name: name, Birthday: date, GroupID: testGroupID}
+	if err := store.AddBirthday(context.Background(), b); err != nil {
+		t.Fatalf("ошибка добавления дня рождения: %v", err)
+	}
+}
+
+func TestCheckBirthdaysSendsEachNotificationOnce(t *testing.T) {
+	s, store, srv, _ := newTestScheduler(t, mustTime(t, "Europe/Moscow", "2026-05-10 09:00"), "09:00")
+	ru := i18n.New(i18n.Russian)
+
+	addBirthday(t, store, "Юбиляр", "1996-05-10")
+	addBirthday(t, store, "Через неделю", "1993-05-17")
+	addBirthday(t, store, "Не скоро", "1985-05-13")
+
+	ctx := context.Background()
+	if err := s.checkBirthdays(ctx); err != nil {
+		t.Fatalf("ошибка проверки дней рождения: %v", err)
+	}
+
+	messages := srv.Messages(testGroupID)
+	if len(messages) != 2 {
+		t.Fatalf("отправлено %d сообщений, ожидалось 2: %+v", len(messages), messages)
+	}
+
+	// Сначала напоминание о самом дальнем дне рождения, затем поздравление
+	reminder := messages[0].Text
+	for _, want := range []string{ru.T("when.week"), "Через неделю", "33 года"} {
+		if !strings.Contains(reminder, want) {
+			t.Errorf("в напоминании нет %q: %q", want, reminder)
+		}
+	}
+	jubilee := messages[1].Text
+	for _, want := range []string{"С юбилеем, Юбиляр!", "30 лет"} {
+		if !strings.Contains(jubilee, want) {
+			t.Errorf("в поздравлении нет %q: %q", want, jubilee)
+		}
+	}
+
+	// Повторная проверка в тот же день ничего не отправляет
+	if err := s.checkBirthdays(ctx); err != nil {
+		t.Fatalf("ошибка повторной проверки: %v", err)
+	}
+	if n := len(srv.Messages(testGroupID)); n != 2 {
+		t.Fatalf("после повторной проверки %d сообщений, ожидалось 2", n)
+	}
+}
+
+func TestCheckBirthdaysUsesGroupSettings(t *testing.T) {
+	s, store, srv, _ := newTestScheduler(t, mustTime(t, "Europe/London", "2026-05-10 09:00"), "09:00")
+	en := i18n.New(i18n.English)
+
+	ctx := context.Background()
+	if err := store.SetLanguage(ctx, testGroupID, string(i18n.English)); err != nil {
+		t.Fatalf("ошибка установки языка: %v", err)
+	}
+	if err := store.SetReminderOffsets(ctx, testGroupID, []int{3}); err != nil {
+		t.Fatalf("ошибка установки сроков напоминаний: %v", err)
+	}
+	if err := store.SetTemplate(ctx, testGroupID, "reminder", "{{.Name}}: {{.DaysUntil}} {{days .DaysUntil}}"); err != nil {
+		t.Fatalf("ошибка установки шаблона: %v", err)
+	}
+
+	addBirthday(t, store, "Kate", "1985-05-13")
+	addBirthday(t, store, "Tom", "1993-05-17")
+
+	if err := s.checkBirthdays(ctx); err != nil {
+		t.Fatalf("ошибка проверки дней рождения: %v", err)
+	}
+
+	messages := srv.Messages(testGroupID)
+	if len(messages) != 1 {
+		t.Fatalf("отправлено %d сообщений, ожидалось 1: %+v", len(messages), messages)
+	}
+	if want := "Kate: 3 " + en.Days(3); messages[0].Text != want {
+		t.Fatalf("напоминание %q, ожидалось %q", messages[0].Text, want)
+	}
+}
+
+func TestCheckBirthdaysDateBoundaries(t *testing.T) {
+	ru := i18n.New(i18n.Russian)
+
+	tests := []struct {
+		name     string
+		tz       string
+		now      string // Местное время в часовом поясе группы
+		notifyAt string
+		birthday string
+		want     string // Срок в тексте уведомления, пустая строка — уведомлений нет
+	}{
+		{
+			name:     "до времени уведомления",
+			tz:       "Europe/Moscow",
+			now:      "2026-05-10 08:59",
+			notifyAt: "09:00",
+			birthday: "1993-05-11",
+		},
+		{
+			name:     "в момент уведомления",
+			tz:       "Europe/Moscow",
+			now:      "2026-05-10 09:00",
+			notifyAt: "09:00",
+			birthday: "1993-05-11",
+			want:     ru.T("when.tomorrow"),
+		},
+		{
+			name:     "за минуту до полуночи",
+			tz:       "Asia/Yekaterinburg",
+			now:      "2026-07-14 23:59",
+			notifyAt: "00:00",
+			birthday: "1993-07-15",
+			want:     ru.T("when.tomorrow"),
+		},
+		{
+			name:     "часовой пояс впереди UTC: в UTC еще вчера",
+			tz:       "Pacific/Auckland",
+			now:      "2026-06-01 08:00",
+			notifyAt: "08:00",
+			birthday: "1993-06-02",
+			want:     ru.T("when.tomorrow"),
+		},
+		{
+			name:     "часовой пояс позади UTC: в UTC уже завтра",
+			tz:       "America/Los_Angeles",
+			now:      "2026-06-01 20:00",
+			notifyAt: "20:00",
+			birthday: "1993-06-02",
+			want:     ru.T("when.tomorrow"),
+		},
+		{
+			name:     "переход через год: неделя до 1 января",
+			tz:       "Europe/Moscow",
+			now:      "2026-12-25 09:00",
+			notifyAt: "09:00",
+			birthday: "1993-01-01",
+			want:     ru.T("when.week"),
+		},
+		{
+			name:     "переход через год: 31 декабря",
+			tz:       "Europe/Moscow",
+			now:      "2026-12-31 09:00",
+			notifyAt: "09:00",
+			birthday: "1993-01-01",
+			want:     ru.T("when.tomorrow"),
+		},
+		{
+			name:     "1 марта в високосный год через два дня",
+			tz:       "Europe/Moscow",
+			now:      "2028-02-28 09:00",
+			notifyAt: "09:00",
+			birthday: "1993-03-01",
+		},
+		{
+			name:     "29 февраля накануне в високосный год",
+			tz:       "Europe/Moscow",
+			now:      "2028-02-28 09:00",
+			notifyAt: "09:00",
+			birthday: "1992-02-29",
+			want:     ru.T("when.tomorrow"),
+		},
+		{
+			name:     "29 февраля за неделю в невисокосный год",
+			tz:       "Europe/Moscow",
+			now:      "2027-02-21 09:00",
+			notifyAt: "09:00",
+			birthday: "1992-02-29",
+			want:     ru.T("when.week"),
+		},
+		{
+			name:     "переход на летнее время",
+			tz:       "Europe/Berlin",
+			now:      "2026-03-29 09:00",
+			notifyAt: "09:00",
+			birthday: "1993-03-30",
+			want:     ru.T("when.tomorrow"),
+		},
+		{
+			name:     "переход на зимнее время",
+			tz:       "America/New_York",
+			now:      "2026-10-25 09:00",
+			notifyAt: "09:00",
+			birthday: "1993-11-01",
+			want:     ru.T("when.week"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, srv, _ := newTestScheduler(t, mustTime(t, tt.tz, tt.now), tt.notifyAt)
+			addBirthday(t, store, "Иван", tt.birthday)
+
+			if err := s.checkBirthdays(context.Background()); err != nil {
+				t.Fatalf("ошибка проверки дней рождения: %v", err)
+			}
+
+			messages := srv.Messages(testGroupID)
+			if tt.want == "" {
+				if len(messages) != 0 {
+					t.Fatalf("отправлено %d сообщений, ожидалось 0: %+v", len(messages), messages)
+				}
+				return
+			}
+			if len(messages) != 1 {
+				t.Fatalf("отправлено %d сообщений, ожидалось 1: %+v", len(messages), messages)
+			}
+			if !strings.Contains(messages[0].Text, tt.want) || !strings.Contains(messages[0].Text, "Иван") {
+				t.Fatalf("в уведомлении %q нет %q", messages[0].Text, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBirthdaysAcrossDays(t *testing.T) {
+	s, store, srv, clk := newTestScheduler(t, mustTime(t, "Europe/Moscow", "2026-12-31 08:00"), "09:00")
+	ru := i18n.New(i18n.Russian)
+	ctx := context.Background()
+
+	addBirthday(t, store, "Новогодний", "1993-01-01")
+
+	// checkBirthdays вызывается планировщиком раз в несколько минут
+	check := func(want int) []tgbotapi.Message {
+		t.Helper()
+		if err := s.checkBirthdays(ctx); err != nil {
+			t.Fatalf("ошибка проверки дней рождения: %v", err)
+		}
+		messages := srv.Messages(testGroupID)
+		if len(messages) != want {
+			t.Fatalf("в %s отправлено %d сообщений, ожидалось %d: %+v", clk.Now().Format("02.01.2006 15:04"), len(messages), want, messages)
+		}
+		return messages
+	}
+
+	check(0)
+
+	clk.Advance(time.Hour)
+	messages := check(1)
+	if !strings.Contains(messages[0].Text, ru.T("when.tomorrow")) {
+		t.Fatalf("в напоминании %q нет %q", messages[0].Text, ru.T("when.tomorrow"))
+	}
+
+	// До конца дня повторных напоминаний нет, после полуночи — ждем времени уведомления
+	clk.Set(mustTime(t, "Europe/Moscow", "2026-12-31 23:59"))
+	check(1)
+	clk.Advance(time.Minute)
+	check(1)
+
+	clk.Set(mustTime(t, "Europe/Moscow", "2027-01-01 09:00"))
+	messages = check(2)
+	if !strings.Contains(messages[1].Text, "Новогодний") || !strings.Contains(messages[1].Text, "34") {
+		t.Fatalf("поздравление %q без имени или возраста", messages[1].Text)
+	}
+	check(2)
+}
+
+func TestCheckBirthdaysPermanentFailures(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("бота удалили из группы", func(t *testing.T) {
+		s, store, srv, clk := newTestScheduler(t, mustTime(t, "Europe/Moscow", "2026-05-10 09:00"), "09:00")
+		addBirthday(t, store, "Иван", "1993-05-11")
+
+		srv.FailNext("sendMessage", 403, 0)
+		if err := s.checkBirthdays(ctx); err != nil {
+			t.Fatalf("ошибка проверки дней рождения: %v", err)
+		}
+
+		groups, err := store.GetAllGroups(ctx)
+		if err != nil {
+			t.Fatalf("ошибка получения групп: %v", err)
+		}
+		if len(groups) != 0 {
+			t.Fatalf("группа осталась активной после ответа 403: %+v", groups)
+		}
+
+		clk.Advance(time.Minute)
+		if err := s.checkBirthdays(ctx); err != nil {
+			t.Fatalf("ошибка повторной проверки: %v", err)
+		}
+		if n := len(srv.Messages(testGroupID)); n != 0 {
+			t.Fatalf("в отключенную группу отправлено %d сообщений", n)
+		}
+	})
+
+	t.Run("запрос отклонен", func(t *testing.T) {
+		s, store, srv, clk := newTestScheduler(t, mustTime(t, "Europe/Moscow", "2026-05-10 09:00"), "09:00")
+		addBirthday(t, store, "Иван", "1993-05-11")
+
+		// check проверяет дни рождения через after и возвращает число сообщений в группе
+		check := func(after time.Duration) int {
+			t.Helper()
+			clk.Advance(after)
+			if err := s.checkBirthdays(ctx); err != nil {
+				t.Fatalf("ошибка проверки дней рождения: %v", err)
+			}
+			return len(srv.Messages(testGroupID))
+		}
+
+		srv.FailNext("sendMessage", 400, 0)
+		srv.FailNext("sendMessage", 400, 0)
+		if n := check(0); n != 0 {
+			t.Fatalf("отправлено %d сообщений, ожидалось 0", n)
+		}
+
+		// Отправка отложена, а не повторяется каждую минуту
+		if n := check(time.Minute); n != 0 {
+			t.Fatalf("через минуту после ошибки отправлено %d сообщений", n)
+		}
+		if n := check(failureBackoff); n != 0 {
+			t.Fatalf("после второй ошибки отправлено %d сообщений", n)
+		}
+
+		// Пауза после второй ошибки вдвое длиннее
+		if n := check(failureBackoff); n != 0 {
+			t.Fatalf("до конца удвоенной паузы отправлено %d сообщений", n)
+		}
+		if n := check(failureBackoff); n != 1 {
+			t.Fatalf("после паузы отправлено %d сообщений, ожидалось 1", n)
+		}
+	})
+}
+
+func TestCheckBirthdaysMigratedGroup(t *testing.T) {
+	const supergroupID = -1002001
+	s, store, srv, clk := newTestScheduler(t, mustTime(t, "Europe/Moscow", "2026-05-10 09:00"), "09:00")
+	addBirthday(t, store, "Иван", "1993-05-11")
+
+	// Сообщение о переносе бот пропустил и узнает о супергруппе из ответа на отправку
+	srv.FailMigrated("sendMessage", supergroupID)
+	ctx := context.Background()
+	if err := s.checkBirthdays(ctx); err != nil {
+		t.Fatalf("ошибка проверки дней рождения: %v", err)
+	}
+
+	if n := len(srv.Messages(testGroupID)); n != 0 {
+		t.Fatalf("в старую группу отправлено %d сообщений", n)
+	}
+	messages := srv.Messages(supergroupID)
+	if len(messages) != 1 || !strings.Contains(messages[0].Text, "Иван") {
+		t.Fatalf("в супергруппу отправлено %+v, ожидалось напоминание", messages)
+	}
+
+	groups, err := store.GetAllGroups(ctx)
+	if err != nil {
+		t.Fatalf("ошибка получения групп: %v", err)
+	}
+	if len(groups) != 1 || groups[0].ID != supergroupID {
+		t.Fatalf("активные группы %+v, ожидалась только супергруппа", groups)
+	}
+
+	// Напоминание записано в журнал супергруппы и не повторяется
+	clk.Advance(time.Minute)
+	if err := s.checkBirthdays(ctx); err != nil {
+		t.Fatalf("ошибка повторной проверки: %v", err)
+	}
+	if n := len(srv.Messages(supergroupID)); n != 1 {
+		t.Fatalf("после повторной проверки в супергруппе %d сообщений, ожидалось 1", n)
+	}
+}
+
+func TestCheckBirthdaysPicksGreetingOnlyWhenShown(t *testing.T) {
+	tests := []struct {
+		name         string
+		template     string // Шаблон поздравления группы, пустой — шаблон по умолчанию
+		wantGreeting bool
+	}{
+		{name: "шаблон по умолчанию", wantGreeting: true},
+		{name: "шаблон с поздравлением", template: "{{.Name}}! {{.Greeting}}", wantGreeting: true},
+		{name: "поздравление через $", template: "{{with .Name}}{{.}}: {{$.Greeting}}{{end}}", wantGreeting: true},
+		{name: "шаблон без поздравления", template: "С днем рождения, {{.Name}}!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, store, srv, _ := newTestScheduler(t, mustTime(t, "Europe/Moscow", "2026-05-10 09:00"), "09:00")
+			addBirthday(t, store, "Иван", "1993-05-10")
+
+			ctx := context.Background()
+			if tt.template != "" {
+				if err := store.SetTemplate(ctx, testGroupID, "birthday", tt.template); err != nil {
+					t.Fatalf("ошибка установки шаблона: %v", err)
+				}
+			}
+			greeting := &models.Greeting{
+				GroupID:   testGroupID,
+				Text:      "Счастья и здоровья!",
+				MediaType: models.MediaSticker,
+				FileID:    "sticker-file",
+			}
+			if err := store.AddGreeting(ctx, greeting); err != nil {
+				t.Fatalf("ошибка добавления поздравления: %v", err)
+			}
+
+			if err := s.checkBirthdays(ctx); err != nil {
+				t.Fatalf("ошибка проверки дней рождения: %v", err)
+			}
+
+			var text string
+			stickers := 0
+			for _, m := range srv.Messages(testGroupID) {
+				if m.Sticker != nil {
+					stickers++
+				} else {
+					text = m.Text
+				}
+			}
+
+			birthdays, err := store.GetBirthdays(ctx, testGroupID)
+			if err != nil {
+				t.Fatalf("ошибка получения дней рождения: %v", err)
+			}
+			recent, err := store.GetRecentGreetings(ctx, testGroupID, birthdays[0].ID, 10)
+			if err != nil {
+				t.Fatalf("ошибка получения истории поздравлений: %v", err)
+			}
+
+			if !tt.wantGreeting {
+				if stickers != 0 || len(recent) != 0 {
+					t.Fatalf("шаблон не выводит поздравление, но оно выбрано: стикеров %d, история %v", stickers, recent)
+				}
+				return
+			}
+
+			// Выбирается встроенное поздравление или поздравление группы; стикер только у второго
+			if len(recent) != 1 {
+				t.Fatalf("в истории %v, ожидалось одно поздравление", recent)
+			}
+			greetings, err := store.GetGreetings(ctx, testGroupID, string(i18n.Russian))
+			if err != nil {
+				t.Fatalf("ошибка получения поздравлений: %v", err)
+			}
+			i := slices.IndexFunc(greetings, func(g *models.Greeting) bool { return g.ID == recent[0] })
+			if i < 0 || !strings.Contains(text, greetings[i].Text) {
+				t.Fatalf("в тексте %q нет выбранного поздравления %d", text, recent[0])
+			}
+			wantStickers := 0
+			if recent[0] == greeting.ID {
+				wantStickers = 1
+			}
+			if stickers != wantStickers {
+				t.Fatalf("отправлено %d стикеров, ожидалось %d", stickers, wantStickers)
+			}
+		})
+	}
+}
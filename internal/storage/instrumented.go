@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"strings"
+	"time"
+
+	"Eldarius_bot/internal/logging"
+	"Eldarius_bot/internal/metrics"
+)
+
+// slowQuery запросы дольше этого записываются в журнал как предупреждения
+const slowQuery = 500 * time.Millisecond
+
+// instrumentedDB соединение с базой, которое замеряет длительность запросов для метрик
+// и записывает запросы в журнал. Запросы внутри транзакций и миграций не замеряются.
+type instrumentedDB struct {
+	*sql.DB
+	logger *slog.Logger
+}
+
+// observe записывает длительность запроса вида op, начатого в start
+func (db instrumentedDB) observe(ctx context.Context, op, query string, start time.Time) {
+	elapsed := time.Since(start)
+	metrics.DBQuery.WithLabelValues(op).Observe(elapsed.Seconds())
+
+	level := slog.LevelDebug
+	if elapsed >= slowQuery {
+		level = slog.LevelWarn
+	}
+	// Журнал из контекста содержит correlation_id обновления, во время которого выполнен запрос
+	logger := logging.FromContext(ctx, db.logger)
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	logger.Log(ctx, level, "Запрос к базе данных",
+		slog.String("op", op),
+		slog.String("query", strings.Join(strings.Fields(query), " ")),
+		slog.Duration("duration", elapsed),
+	)
+}
+
+// ExecContext выполняет запрос без результата
+func (db instrumentedDB) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	defer db.observe(ctx, "exec", query, time.Now())
+	return db.DB.ExecContext(ctx, query, args...)
+}
+
+// QueryContext выполняет запрос, возвращающий строки.
+// Длительность замеряется до закрытия строк, потому что SQLite выполняет запрос по мере их чтения.
+func (db instrumentedDB) QueryContext(ctx context.Context, query string, args ...any) (*instrumentedRows, error) {
+	start := time.Now()
+	rows, err := db.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		db.observe(ctx, "query", query, start)
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, done: func() { db.observe(ctx, "query", query, start) }}, nil
+}
+
+// QueryRowContext выполняет запрос, возвращающий не больше одной строки.
+// Длительность замеряется вместе со Scan, в котором SQLite и выполняет запрос.
+func (db instrumentedDB) QueryRowContext(ctx context.Context, query string, args ...any) *instrumentedRow {
+	start := time.Now()
+	row := db.DB.QueryRowContext(ctx, query, args...)
+	return &instrumentedRow{Row: row, done: func() { db.observe(ctx, "query", query, start) }}
+}
+
+// instrumentedRows строки результата, которые записывают длительность запроса при закрытии
+type instrumentedRows struct {
+	*sql.Rows
+	done func()
+}
+
+// Close закрывает строки и записывает длительность запроса
+func (r *instrumentedRows) Close() error {
+	defer r.done()
+	return r.Rows.Close()
+}
+
+// instrumentedRow строка результата, которая записывает длительность запроса после Scan
+type instrumentedRow struct {
+	*sql.Row
+	done func()
+}
+
+// Scan копирует значения строки в dest и записывает длительность запроса
+func (r *instrumentedRow) Scan(dest ...any) error {
+	defer r.done()
+	return r.Row.Scan(dest...)
+}
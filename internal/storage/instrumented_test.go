@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"Eldarius_bot/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// queryCount возвращает число замеренных запросов на чтение
+func queryCount(t *testing.T) uint64 {
+	t.Helper()
+
+	var m dto.Metric
+	if err := metrics.DBQuery.WithLabelValues("query").(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("ошибка чтения метрики: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestInstrumentedQueriesObservedAfterReading(t *testing.T) {
+	store := openSQLite(t, filepath.Join(t.TempDir(), "birthdays.db"))
+	ctx := context.Background()
+
+	// SQLite выполняет запрос одной строки только в Scan, поэтому и замер заканчивается в нем
+	before := queryCount(t)
+	row := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM birthdays`)
+	if got := queryCount(t); got != before {
+		t.Fatalf("запрос замерен до Scan")
+	}
+	var count int
+	if err := row.Scan(&count); err != nil {
+		t.Fatalf("ошибка чтения строки: %v", err)
+	}
+	if got := queryCount(t); got != before+1 {
+		t.Fatalf("после Scan замерено %d запросов, ожидался 1", got-before)
+	}
+
+	// Запрос нескольких строк замеряется до их закрытия
+	rows, err := store.db.QueryContext(ctx, `SELECT id FROM groups`)
+	if err != nil {
+		t.Fatalf("ошибка запроса: %v", err)
+	}
+	for rows.Next() {
+	}
+	if got := queryCount(t); got != before+1 {
+		t.Fatalf("запрос строк замерен до закрытия")
+	}
+	if err := rows.Close(); err != nil {
+		t.Fatalf("ошибка закрытия строк: %v", err)
+	}
+	if got := queryCount(t); got != before+2 {
+		t.Fatalf("после закрытия строк замерено %d запросов, ожидался 1", got-before-1)
+	}
+}
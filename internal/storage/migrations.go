@@ -0,0 +1,662 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+// migration описывает один шаг изменения схемы базы данных
+type migration struct {
+	version int
+	name    string
+	up      func(ctx context.Context, tx *sql.Tx) error
+	down    func(ctx context.Context, tx *sql.Tx) error
+}
+
+// migrations содержит все шаги миграции в порядке возрастания версии.
+// Уже выпущенные шаги менять нельзя: новые изменения схемы добавляются в конец списка.
+var migrations = []migration{
+	{
+		version: 1,
+		name:    "initial_schema",
+		up:      upInitialSchema,
+		down:    downInitialSchema,
+	},
+	{
+		version: 2,
+		name:    "legacy_name_columns",
+		up:      upLegacyNameColumns,
+		down:    downLegacyNameColumns,
+	},
+	{
+		version: 3,
+		name:    "settings_timezone",
+		up:      upSettingsTimezone,
+		down:    downSettingsTimezone,
+	},
+	{
+		version: 4,
+		name:    "notifications_sent",
+		up:      upNotificationsSent,
+		down:    downNotificationsSent,
+	},
+	{
+		version: 5,
+		name:    "reminder_offsets",
+		up:      upReminderOffsets,
+		down:    downReminderOffsets,
+	},
+	{
+		version: 6,
+		name:    "conversations",
+		up:      upConversations,
+		down:    downConversations,
+	},
+	{
+		version: 7,
+		name:    "group_editors",
+		up:      upGroupEditors,
+		down:    downGroupEditors,
+	},
+	{
+		version: 8,
+		name:    "birthdays_year_unknown",
+		up:      upBirthdaysYearUnknown,
+		down:    downBirthdaysYearUnknown,
+	},
+	{
+		version: 9,
+		name:    "message_templates",
+		up:      upMessageTemplates,
+		down:    downMessageTemplates,
+	},
+	{
+		version: 10,
+		name:    "greetings",
+		up:      upGreetings,
+		down:    downGreetings,
+	},
+	{
+		version: 11,
+		name:    "group_language",
+		up:      upGroupLanguage,
+		down:    downGroupLanguage,
+	},
+	{
+		version: 12,
+		name:    "groups_active",
+		up:      upGroupsActive,
+		down:    downGroupsActive,
+	},
+}
+
+// LatestSchemaVersion возвращает номер последней известной версии схемы
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// ensureMigrationsTable создает таблицу учета примененных миграций
+func ensureMigrationsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("ошибка создания таблицы миграций: %w", err)
+	}
+
+	return nil
+}
+
+// schemaVersion возвращает номер последней примененной миграции
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(version), 0) FROM schema_migrations
+	`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения версии схемы: %w", err)
+	}
+
+	return version, nil
+}
+
+// migrateTo приводит схему базы данных к указанной версии,
+// применяя шаги up или откатывая шаги down по порядку
+func migrateTo(ctx context.Context, db *sql.DB, target int, logger *slog.Logger) error {
+	if target < 0 || target > LatestSchemaVersion() {
+		return fmt.Errorf("неизвестная версия схемы: %d", target)
+	}
+
+	if err := ensureMigrationsTable(ctx, db); err != nil {
+		return err
+	}
+
+	current, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if current > LatestSchemaVersion() {
+		return fmt.Errorf("версия схемы %d новее поддерживаемой (%d)", current, LatestSchemaVersion())
+	}
+
+	// Применяем недостающие миграции
+	for _, m := range migrations {
+		if m.version <= current || m.version > target {
+			continue
+		}
+		if err := applyMigration(ctx, db, m, true); err != nil {
+			return err
+		}
+		logger.Info("Применена миграция схемы", slog.Int("version", m.version), slog.String("name", m.name))
+	}
+
+	// Откатываем лишние миграции в обратном порядке
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.version > current || m.version <= target {
+			continue
+		}
+		if err := applyMigration(ctx, db, m, false); err != nil {
+			return err
+		}
+		logger.Info("Откачена миграция схемы", slog.Int("version", m.version), slog.String("name", m.name))
+	}
+
+	return nil
+}
+
+// applyMigration выполняет один шаг миграции в отдельной транзакции
+func applyMigration(ctx context.Context, db *sql.DB, m migration, up bool) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции миграции %d: %w", m.version, err)
+	}
+	defer tx.Rollback()
+
+	if up {
+		if err := m.up(ctx, tx); err != nil {
+			return fmt.Errorf("ошибка применения миграции %d (%s): %w", m.version, m.name, err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO schema_migrations (version, name)
+			VALUES (?, ?)
+		`, m.version, m.name)
+	} else {
+		if err := m.down(ctx, tx); err != nil {
+			return fmt.Errorf("ошибка отката миграции %d (%s): %w", m.version, m.name, err)
+		}
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM schema_migrations WHERE version = ?
+		`, m.version)
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка записи версии миграции %d: %w", m.version, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации миграции %d: %w", m.version, err)
+	}
+
+	return nil
+}
+
+// execAll выполняет несколько SQL-выражений подряд
+func execAll(ctx context.Context, tx *sql.Tx, statements ...string) error {
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnExists проверяет наличие колонки в таблице
+func columnExists(ctx context.Context, tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("ошибка чтения структуры таблицы %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, fmt.Errorf("ошибка сканирования структуры таблицы %s: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// addColumnIfMissing добавляет колонку, если ее еще нет в таблице
+func addColumnIfMissing(ctx context.Context, tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(ctx, tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// upInitialSchema создает базовые таблицы.
+// Выражения идемпотентны, чтобы миграция проходила и на базах,
+// созданных до появления учета версий.
+func upInitialSchema(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx,
+		`CREATE TABLE IF NOT EXISTS groups (
+			id INTEGER PRIMARY KEY,
+			title TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS birthdays (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			birthday DATE NOT NULL,
+			group_id INTEGER NOT NULL,
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE IF NOT EXISTS settings (
+			group_id INTEGER PRIMARY KEY,
+			notify_time TEXT NOT NULL,
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+		)`,
+	)
+}
+
+// downInitialSchema удаляет базовые таблицы
+func downInitialSchema(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx,
+		`DROP TABLE IF EXISTS settings`,
+		`DROP TABLE IF EXISTS birthdays`,
+		`DROP TABLE IF EXISTS groups`,
+	)
+}
+
+// upLegacyNameColumns переводит таблицу birthdays из схемы старого бинарника
+// (first_name + last_name) в схему с единой колонкой name.
+// На базах, уже использующих колонку name, миграция ничего не делает.
+func upLegacyNameColumns(ctx context.Context, tx *sql.Tx) error {
+	legacy, err := columnExists(ctx, tx, "birthdays", "first_name")
+	if err != nil {
+		return err
+	}
+	if !legacy {
+		return nil
+	}
+
+	return execAll(ctx, tx,
+		`CREATE TABLE birthdays_new (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			birthday DATE NOT NULL,
+			group_id INTEGER NOT NULL,
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+		)`,
+		`INSERT INTO birthdays_new (id, name, birthday, group_id)
+		SELECT id, TRIM(COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')), birthday, group_id
+		FROM birthdays`,
+		`DROP TABLE birthdays`,
+		`ALTER TABLE birthdays_new RENAME TO birthdays`,
+		// Старый бинарник мог хранить дни рождения групп, которых нет в таблице groups
+		`INSERT OR IGNORE INTO groups (id, title)
+		SELECT DISTINCT group_id, '' FROM birthdays`,
+	)
+}
+
+// downLegacyNameColumns возвращает схему first_name + last_name,
+// разделяя name по первому пробелу
+func downLegacyNameColumns(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx,
+		`CREATE TABLE birthdays_old (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			first_name TEXT NOT NULL,
+			last_name TEXT NOT NULL,
+			birthday DATE NOT NULL,
+			group_id INTEGER NOT NULL,
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+		)`,
+		`INSERT INTO birthdays_old (id, first_name, last_name, birthday, group_id)
+		SELECT id,
+			CASE WHEN instr(name, ' ') > 0 THEN substr(name, 1, instr(name, ' ') - 1) ELSE name END,
+			CASE WHEN instr(name, ' ') > 0 THEN substr(name, instr(name, ' ') + 1) ELSE '' END,
+			birthday, group_id
+		FROM birthdays`,
+		`DROP TABLE birthdays`,
+		`ALTER TABLE birthdays_old RENAME TO birthdays`,
+	)
+}
+
+// upSettingsTimezone добавляет часовой пояс группы в настройки
+func upSettingsTimezone(ctx context.Context, tx *sql.Tx) error {
+	return addColumnIfMissing(ctx, tx, "settings", "timezone",
+		fmt.Sprintf("TEXT NOT NULL DEFAULT '%s'", defaultTimezone))
+}
+
+// downSettingsTimezone удаляет часовой пояс из настроек
+func downSettingsTimezone(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx, `ALTER TABLE settings DROP COLUMN timezone`)
+}
+
+// upNotificationsSent создает журнал отправленных уведомлений.
+// Запись однозначно определяется группой, днем рождения, датой его наступления
+// и количеством дней до него, поэтому каждое уведомление отправляется один раз.
+func upNotificationsSent(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx,
+		`CREATE TABLE IF NOT EXISTS notifications_sent (
+			group_id INTEGER NOT NULL,
+			birthday_id INTEGER NOT NULL,
+			occurrence TEXT NOT NULL,
+			days_before INTEGER NOT NULL,
+			sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (group_id, birthday_id, occurrence, days_before)
+		)`,
+	)
+}
+
+// downNotificationsSent удаляет журнал отправленных уведомлений
+func downNotificationsSent(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx, `DROP TABLE IF EXISTS notifications_sent`)
+}
+
+// upReminderOffsets создает таблицу сроков напоминаний групп
+func upReminderOffsets(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx,
+		`CREATE TABLE IF NOT EXISTS reminder_offsets (
+			group_id INTEGER NOT NULL,
+			days_before INTEGER NOT NULL,
+			PRIMARY KEY (group_id, days_before),
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+		)`,
+	)
+}
+
+// downReminderOffsets удаляет таблицу сроков напоминаний
+func downReminderOffsets(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx, `DROP TABLE IF EXISTS reminder_offsets`)
+}
+
+// upConversations создает таблицу состояний пошаговых диалогов
+func upConversations(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx,
+		`CREATE TABLE IF NOT EXISTS conversations (
+			chat_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			flow TEXT NOT NULL,
+			step TEXT NOT NULL,
+			data TEXT NOT NULL DEFAULT '{}',
+			updated_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (chat_id, user_id)
+		)`,
+	)
+}
+
+// downConversations удаляет таблицу состояний диалогов
+func downConversations(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx, `DROP TABLE IF EXISTS conversations`)
+}
+
+// upGroupEditors создает таблицу дополнительных редакторов групп
+func upGroupEditors(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx,
+		`CREATE TABLE IF NOT EXISTS group_editors (
+			group_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			name TEXT NOT NULL DEFAULT '',
+			PRIMARY KEY (group_id, user_id),
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+		)`,
+	)
+}
+
+// downGroupEditors удаляет таблицу редакторов групп
+func downGroupEditors(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx, `DROP TABLE IF EXISTS group_editors`)
+}
+
+// seedGroupID группа, которую заполнял init_birthdays.sql
+const seedGroupID = -1001932668989
+
+// seedBirthdays дни рождения из init_birthdays.sql: для них были известны только день и месяц,
+// и они сохранялись с годом-заглушкой models.UnknownYear
+var seedBirthdays = []struct {
+	name     string
+	birthday string
+}{
+	{"Эдуард Джендубаев", "2000-01-02"},
+	{"Теодор Джендубаев", "2000-01-08"},
+	{"Роберт Джендубаев", "2000-01-11"},
+	{"Анна Джендубаева", "2000-01-25"},
+	{"Зураб Джендубаев", "2000-01-27"},
+	{"Демис Полантис", "2000-02-05"},
+	{"Альберт Марчануков", "2000-03-02"},
+	{"Ислам Кнухов", "2000-03-08"},
+	{"Белла Джендубаева", "2000-03-15"},
+	{"Джамал Кнухов", "2000-03-19"},
+	{"Дамир Джендубаев", "2000-03-19"},
+	{"Адам Дагужиев", "2000-04-01"},
+	{"Азамат Джендубаев", "2000-04-22"},
+	{"Шахимби Дагужиев", "2000-05-08"},
+	{"Закария Полантис", "2000-05-14"},
+	{"Алина Кнухова", "2000-05-28"},
+	{"Аза Марчанукова", "2000-05-31"},
+	{"Данияр Джендубаев", "2000-06-05"},
+	{"Сафарби Кнухов", "2000-06-11"},
+	{"Аслан Джендубаев", "2000-06-14"},
+	{"Артур Марчануков", "2000-06-16"},
+	{"Аскар Шанов", "2000-06-26"},
+	{"Диана Джендубаева", "2000-06-27"},
+	{"Александра Джендубаева", "2000-07-09"},
+	{"Роман Кнухов", "2000-07-15"},
+	{"Армида Дагужиева", "2000-08-08"},
+	{"Инал Дагужиев", "2000-08-19"},
+	{"Фатима Джендубаева", "2000-09-02"},
+	{"Аюб Дагужиев", "2000-09-05"},
+	{"Индар Джендубаев", "2000-09-08"},
+	{"Татьяна Кнухова", "2000-09-19"},
+	{"Эльдар Джендубаев", "2000-10-02"},
+	{"Эмир Чагов", "2000-10-09"},
+	{"Самира Джендубаева", "2000-10-27"},
+	{"Ариза Кнухова", "2000-10-28"},
+	{"Залина Дагужиева", "2000-11-26"},
+	{"Ксения Джендубаева", "2000-11-30"},
+	{"Заур Джендубаев", "2000-12-10"},
+	{"Аделина Марчанукова", "2000-12-22"},
+	{"Тамина Джендубаева", "2000-12-22"},
+	{"Светлана Джендубаева", "2000-12-23"},
+	{"Фуад Гедегулов", "2000-12-29"},
+}
+
+// upBirthdaysYearUnknown добавляет признак неизвестного года рождения.
+// Год без проверки считается неизвестным только у записей, которые добавил init_birthdays.sql.
+// Остальные записи 2000 года могут быть настоящими датами, поэтому они сохраняют год,
+// а редакторы при необходимости убирают его сами.
+func upBirthdaysYearUnknown(ctx context.Context, tx *sql.Tx) error {
+	if err := addColumnIfMissing(ctx, tx, "birthdays", "year_unknown", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	for _, b := range seedBirthdays {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE birthdays SET year_unknown = 1
+			WHERE group_id = ? AND name = ? AND substr(birthday, 1, 10) = ?
+		`, seedGroupID, b.name, b.birthday)
+		if err != nil {
+			return fmt.Errorf("ошибка отметки дня рождения без года: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// downBirthdaysYearUnknown удаляет признак неизвестного года рождения.
+// Даты таких записей остаются с годом-заглушкой.
+func downBirthdaysYearUnknown(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx, `ALTER TABLE birthdays DROP COLUMN year_unknown`)
+}
+
+// upMessageTemplates создает таблицу шаблонов уведомлений групп
+func upMessageTemplates(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx,
+		`CREATE TABLE IF NOT EXISTS message_templates (
+			group_id INTEGER NOT NULL,
+			kind TEXT NOT NULL,
+			body TEXT NOT NULL,
+			PRIMARY KEY (group_id, kind),
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+		)`,
+	)
+}
+
+// downMessageTemplates удаляет таблицу шаблонов уведомлений
+func downMessageTemplates(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx, `DROP TABLE IF EXISTS message_templates`)
+}
+
+// builtinGreetings встроенные поздравления, доступные всем группам
+var builtinGreetings = []string{
+	"Пусть этот день будет особенным и запомнится только радостными моментами! " +
+		"Желаем тебе счастья, успехов во всех начинаниях и исполнения всех желаний! " +
+		"Пусть каждый день приносит радость и улыбку! 🌟",
+	"Желаем крепкого здоровья, верных друзей и близких людей рядом. " +
+		"Пусть сбываются мечты, а каждый новый год жизни будет лучше предыдущего! 🎈",
+	"Пусть в жизни будет больше ярких событий, приятных сюрпризов и поводов для гордости. " +
+		"Вдохновения, удачи и тепла в доме! 🎁",
+	"Желаем, чтобы работа приносила удовольствие, отдых — силы, а близкие — радость. " +
+		"Пусть все задуманное обязательно получится! 🚀",
+	"Пусть каждый день дарит улыбки, а рядом всегда будут люди, которые ценят и поддерживают. " +
+		"Счастья, любви и благополучия! 💐",
+	"Желаем легкости в делах, гармонии в душе и уверенности в завтрашнем дне. " +
+		"Пусть этот год станет годом новых открытий! ✨",
+	"Здоровья, оптимизма и побольше поводов для праздника! " +
+		"Пусть удача сопутствует во всем, а мечты становятся планами и сбываются! 🥳",
+}
+
+// upGreetings создает библиотеку поздравлений со встроенными поздравлениями
+// и историю их использования, чтобы поздравления одному человеку не повторялись подряд
+func upGreetings(ctx context.Context, tx *sql.Tx) error {
+	err := execAll(ctx, tx,
+		`CREATE TABLE IF NOT EXISTS greetings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			group_id INTEGER NOT NULL,
+			text TEXT NOT NULL,
+			media_type TEXT NOT NULL DEFAULT '',
+			file_id TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE INDEX IF NOT EXISTS greetings_group_id ON greetings (group_id)`,
+		`CREATE TABLE IF NOT EXISTS greeting_history (
+			group_id INTEGER NOT NULL,
+			birthday_id INTEGER NOT NULL,
+			greeting_id INTEGER NOT NULL,
+			used_at TIMESTAMP NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS greeting_history_birthday ON greeting_history (group_id, birthday_id, used_at)`,
+	)
+	if err != nil {
+		return err
+	}
+
+	var builtins int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM greetings WHERE group_id = 0`).Scan(&builtins); err != nil {
+		return fmt.Errorf("ошибка подсчета встроенных поздравлений: %w", err)
+	}
+	if builtins > 0 {
+		return nil
+	}
+
+	for _, text := range builtinGreetings {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO greetings (group_id, text) VALUES (0, ?)`, text); err != nil {
+			return fmt.Errorf("ошибка добавления встроенного поздравления: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// downGreetings удаляет библиотеку поздравлений и историю их использования
+func downGreetings(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx,
+		`DROP TABLE IF EXISTS greeting_history`,
+		`DROP TABLE IF EXISTS greetings`,
+	)
+}
+
+// builtinGreetingsEnglish встроенные поздравления для групп с английским языком
+var builtinGreetingsEnglish = []string{
+	"May this day be special and full of happy moments! " +
+		"We wish you happiness, success in everything you do and all your wishes coming true! 🌟",
+	"Wishing you good health, true friends and loved ones by your side. " +
+		"May your dreams come true and every new year be better than the last! 🎈",
+	"May your life be full of bright events, pleasant surprises and reasons to be proud. " +
+		"Inspiration, luck and warmth at home! 🎁",
+	"May work bring you joy, rest bring you strength and your loved ones bring you happiness. " +
+		"May everything you plan work out! 🚀",
+	"Here's to more smiles, more adventures and more reasons to celebrate. Have a wonderful year! 🥳",
+}
+
+// upGroupLanguage добавляет язык группы в настройки и язык встроенных поздравлений.
+// Уже существующие встроенные поздравления русские.
+func upGroupLanguage(ctx context.Context, tx *sql.Tx) error {
+	if err := addColumnIfMissing(ctx, tx, "settings", "language",
+		fmt.Sprintf("TEXT NOT NULL DEFAULT '%s'", defaultLanguage)); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(ctx, tx, "greetings", "lang", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := execAll(ctx, tx, `UPDATE greetings SET lang = 'ru' WHERE group_id = 0 AND lang = ''`); err != nil {
+		return err
+	}
+
+	var builtins int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM greetings WHERE group_id = 0 AND lang = 'en'`).Scan(&builtins); err != nil {
+		return fmt.Errorf("ошибка подсчета встроенных поздравлений: %w", err)
+	}
+	if builtins > 0 {
+		return nil
+	}
+
+	for _, text := range builtinGreetingsEnglish {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO greetings (group_id, text, lang) VALUES (0, ?, 'en')`, text); err != nil {
+			return fmt.Errorf("ошибка добавления встроенного поздравления: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// downGroupLanguage удаляет язык группы и английские встроенные поздравления
+func downGroupLanguage(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx,
+		`DELETE FROM greetings WHERE group_id = 0 AND lang = 'en'`,
+		`ALTER TABLE greetings DROP COLUMN lang`,
+		`ALTER TABLE settings DROP COLUMN language`,
+	)
+}
+
+// upGroupsActive добавляет признак того, что бот состоит в группе.
+// Группы, из которых бота удалили, планировщик пропускает.
+func upGroupsActive(ctx context.Context, tx *sql.Tx) error {
+	return addColumnIfMissing(ctx, tx, "groups", "active", "INTEGER NOT NULL DEFAULT 1")
+}
+
+// downGroupsActive удаляет признак активности группы
+func downGroupsActive(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx, `ALTER TABLE groups DROP COLUMN active`)
+}
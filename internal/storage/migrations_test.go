@@ -0,0 +1,245 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/logging"
+)
+
+// createLegacyDB создает базу в схеме старого бинарника (first_name + last_name,
+// без таблицы schema_migrations), заполняет ее statements и возвращает путь к файлу
+func createLegacyDB(t *testing.T, statements ...string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "birthdays.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("ошибка открытия базы: %v", err)
+	}
+	defer db.Close()
+
+	schema := []string{
+		`CREATE TABLE groups (
+			id INTEGER PRIMARY KEY,
+			title TEXT NOT NULL
+		)`,
+		`CREATE TABLE birthdays (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			first_name TEXT NOT NULL,
+			last_name TEXT NOT NULL,
+			birthday DATE NOT NULL,
+			group_id INTEGER NOT NULL,
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+		)`,
+		`CREATE TABLE settings (
+			group_id INTEGER PRIMARY KEY,
+			notify_time TEXT NOT NULL,
+			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
+		)`,
+	}
+	for _, stmt := range append(schema, statements...) {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("ошибка подготовки базы: %v\n%s", err, stmt)
+		}
+	}
+
+	return path
+}
+
+// openSQLite открывает хранилище, применяя миграции
+func openSQLite(t *testing.T, path string) *SQLite {
+	t.Helper()
+
+	store, err := NewSQLite(path, clock.Real{}, logging.Discard())
+	if err != nil {
+		t.Fatalf("ошибка открытия хранилища: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+// birthdayNames возвращает имена дней рождения группы по ID записи
+func birthdayNames(t *testing.T, store *SQLite, groupID int64) map[int64]string {
+	t.Helper()
+
+	birthdays, err := store.GetBirthdays(context.Background(), groupID)
+	if err != nil {
+		t.Fatalf("ошибка получения дней рождения: %v", err)
+	}
+
+	names := make(map[int64]string, len(birthdays))
+	for _, b := range birthdays {
+		names[b.ID] = b.Name
+	}
+	return names
+}
+
+func TestNewSQLiteMigratesLegacyNames(t *testing.T) {
+	path := createLegacyDB(t,
+		`INSERT INTO groups (id, title) VALUES (-1001932668989, 'Birthday Group')`,
+		`INSERT INTO settings (group_id, notify_time) VALUES (-1001932668989, '08:30')`,
+		`INSERT INTO birthdays (id, first_name, last_name, birthday, group_id) VALUES
+			(1, 'Эдуард', 'Джендубаев', '2000-01-02', -1001932668989),
+			(2, 'Анна', '', '2000-01-25', -1001932668989),
+			(3, 'Роман', 'Кнухов', '1990-07-15', -1002)`,
+	)
+
+	store := openSQLite(t, path)
+	ctx := context.Background()
+
+	version, err := store.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatalf("ошибка получения версии схемы: %v", err)
+	}
+	if version != LatestSchemaVersion() {
+		t.Errorf("версия схемы = %d, ожидалась %d", version, LatestSchemaVersion())
+	}
+
+	want := map[int64]string{1: "Эдуард Джендубаев", 2: "Анна"}
+	got := birthdayNames(t, store, seedGroupID)
+	if len(got) != len(want) {
+		t.Fatalf("дни рождения группы = %v, ожидались %v", got, want)
+	}
+	for id, name := range want {
+		if got[id] != name {
+			t.Errorf("имя записи %d = %q, ожидалось %q", id, got[id], name)
+		}
+	}
+
+	// Группа без записи в groups создается при переносе, чтобы не потерять ее дни рождения
+	if got := birthdayNames(t, store, -1002); got[3] != "Роман Кнухов" {
+		t.Errorf("дни рождения группы без записи = %v", got)
+	}
+	if _, err := store.GetGroup(ctx, -1002); err != nil {
+		t.Errorf("группа без записи не создана: %v", err)
+	}
+
+	notifyTime, err := store.GetNotifyTime(ctx, seedGroupID)
+	if err != nil {
+		t.Fatalf("ошибка получения времени уведомления: %v", err)
+	}
+	if got := notifyTime.Format("15:04"); got != "08:30" {
+		t.Errorf("время уведомления = %s, ожидалось 08:30", got)
+	}
+}
+
+func TestYearUnknownOnlyForSeedBirthdays(t *testing.T) {
+	path := createLegacyDB(t,
+		`INSERT INTO groups (id, title) VALUES (-1001932668989, 'Birthday Group'), (-1002, 'Друзья')`,
+		`INSERT INTO birthdays (id, first_name, last_name, birthday, group_id) VALUES
+			(1, 'Эдуард', 'Джендубаев', '2000-01-02', -1001932668989),
+			(2, 'Иван', 'Петров', '2000-01-02 00:00:00+00:00', -1001932668989),
+			(3, 'Эдуард', 'Джендубаев', '2000-01-02', -1002),
+			(4, 'Мария', 'Иванова', '2000-04-15 00:00:00+00:00', -1002)`,
+	)
+
+	store := openSQLite(t, path)
+
+	tests := []struct {
+		name            string
+		groupID         int64
+		id              int64
+		wantYearUnknown bool
+	}{
+		{name: "запись из init_birthdays.sql", groupID: seedGroupID, id: 1, wantYearUnknown: true},
+		{name: "другой человек 2000 года в той же группе", groupID: seedGroupID, id: 2},
+		{name: "совпадение с init_birthdays.sql в другой группе", groupID: -1002, id: 3},
+		{name: "настоящий день рождения 2000 года", groupID: -1002, id: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := store.GetBirthday(context.Background(), tt.groupID, tt.id)
+			if err != nil {
+				t.Fatalf("ошибка получения дня рождения: %v", err)
+			}
+			if b.YearUnknown != tt.wantYearUnknown {
+				t.Errorf("YearUnknown = %v, ожидалось %v", b.YearUnknown, tt.wantYearUnknown)
+			}
+			if b.Birthday.Year() != 2000 {
+				t.Errorf("год = %d, ожидался 2000", b.Birthday.Year())
+			}
+		})
+	}
+}
+
+func TestMigrateDownAndUpEveryStep(t *testing.T) {
+	path := createLegacyDB(t,
+		`INSERT INTO groups (id, title) VALUES (-1001, 'Друзья')`,
+		`INSERT INTO birthdays (id, first_name, last_name, birthday, group_id) VALUES
+			(1, 'Иван', 'Петров Сидоров', '1990-05-10', -1001)`,
+	)
+
+	store := openSQLite(t, path)
+	ctx := context.Background()
+
+	assertVersion := func(want int) {
+		t.Helper()
+		got, err := store.SchemaVersion(ctx)
+		if err != nil {
+			t.Fatalf("ошибка получения версии схемы: %v", err)
+		}
+		if got != want {
+			t.Fatalf("версия схемы = %d, ожидалась %d", got, want)
+		}
+	}
+
+	// Откатываем по одному шагу до схемы старого бинарника
+	for v := LatestSchemaVersion() - 1; v >= 1; v-- {
+		if err := store.Migrate(ctx, v); err != nil {
+			t.Fatalf("откат до версии %d: %v", v, err)
+		}
+		assertVersion(v)
+	}
+
+	var firstName, lastName string
+	err := store.db.QueryRowContext(ctx, `
+		SELECT first_name, last_name FROM birthdays WHERE id = 1
+	`).Scan(&firstName, &lastName)
+	if err != nil {
+		t.Fatalf("ошибка чтения схемы first_name + last_name: %v", err)
+	}
+	if firstName != "Иван" || lastName != "Петров Сидоров" {
+		t.Errorf("после отката имя = %q + %q, ожидалось \"Иван\" + \"Петров Сидоров\"", firstName, lastName)
+	}
+
+	// Применяем по одному шагу обратно
+	for v := 2; v <= LatestSchemaVersion(); v++ {
+		if err := store.Migrate(ctx, v); err != nil {
+			t.Fatalf("применение версии %d: %v", v, err)
+		}
+		assertVersion(v)
+	}
+
+	if got := birthdayNames(t, store, -1001); got[1] != "Иван Петров Сидоров" {
+		t.Errorf("после повторного применения дни рождения = %v", got)
+	}
+
+	// Полный откат удаляет все таблицы, и схема создается заново с нуля
+	if err := store.Migrate(ctx, 0); err != nil {
+		t.Fatalf("полный откат: %v", err)
+	}
+	assertVersion(0)
+	if err := store.Migrate(ctx, LatestSchemaVersion()); err != nil {
+		t.Fatalf("применение всех миграций: %v", err)
+	}
+	assertVersion(LatestSchemaVersion())
+	if got := birthdayNames(t, store, -1001); len(got) != 0 {
+		t.Errorf("после полного отката остались дни рождения: %v", got)
+	}
+}
+
+func TestMigrateRejectsUnknownVersion(t *testing.T) {
+	store := openSQLite(t, filepath.Join(t.TempDir(), "birthdays.db"))
+
+	for _, v := range []int{-1, LatestSchemaVersion() + 1} {
+		if err := store.Migrate(context.Background(), v); err == nil {
+			t.Errorf("Migrate(%d) без ошибки", v)
+		}
+	}
+}
@@ -12,18 +12,57 @@ type Repository interface {
 	// Методы для работы с днями рождения
 	AddBirthday(ctx context.Context, birthday *models.Birthday) error
 	GetBirthdays(ctx context.Context, groupID int64) ([]*models.Birthday, error)
+	GetBirthday(ctx context.Context, groupID int64, id int64) (*models.Birthday, error)
+	UpdateBirthday(ctx context.Context, birthday *models.Birthday) error
 	DeleteBirthday(ctx context.Context, groupID int64, id int64) error
 	GetUpcomingBirthdays(ctx context.Context, groupID int64, days int) ([]*models.Birthday, error)
 
 	// Методы для работы с группами
 	AddGroup(ctx context.Context, group *models.Group) error
+	EnsureGroup(ctx context.Context, group *models.Group) error
 	GetGroup(ctx context.Context, id int64) (*models.Group, error)
 	GetAllGroups(ctx context.Context) ([]*models.Group, error)
+	SetGroupActive(ctx context.Context, groupID int64, active bool) error
+	MigrateGroup(ctx context.Context, oldID, newID int64) error
 
 	// Методы для работы с настройками
 	GetNotifyTime(ctx context.Context, groupID int64) (time.Time, error)
 	SetNotifyTime(ctx context.Context, groupID int64, t time.Time) error
+	GetTimezone(ctx context.Context, groupID int64) (*time.Location, error)
+	SetTimezone(ctx context.Context, groupID int64, loc *time.Location) error
+	GetLanguage(ctx context.Context, groupID int64) (string, error)
+	SetLanguage(ctx context.Context, groupID int64, lang string) error
+	GetReminderOffsets(ctx context.Context, groupID int64) ([]int, error)
+	SetReminderOffsets(ctx context.Context, groupID int64, offsets []int) error
+
+	// Методы для работы с журналом уведомлений
+	IsNotificationSent(ctx context.Context, groupID, birthdayID int64, occurrence time.Time, daysBefore int) (bool, error)
+	MarkNotificationSent(ctx context.Context, groupID, birthdayID int64, occurrence time.Time, daysBefore int) error
+
+	// Методы для работы с редакторами групп
+	AddEditor(ctx context.Context, editor *models.Editor) error
+	RemoveEditor(ctx context.Context, groupID, userID int64) error
+	GetEditors(ctx context.Context, groupID int64) ([]*models.Editor, error)
+	IsEditor(ctx context.Context, groupID, userID int64) (bool, error)
+
+	// Методы для работы с состояниями диалогов
+	GetConversation(ctx context.Context, chatID, userID int64) (*models.Conversation, error)
+	SaveConversation(ctx context.Context, conv *models.Conversation) error
+	DeleteConversation(ctx context.Context, chatID, userID int64) error
+
+	// Методы для работы с шаблонами уведомлений
+	GetTemplate(ctx context.Context, groupID int64, kind string) (string, error)
+	SetTemplate(ctx context.Context, groupID int64, kind, body string) error
+	DeleteTemplate(ctx context.Context, groupID int64, kind string) error
+
+	// Методы для работы с библиотекой поздравлений
+	AddGreeting(ctx context.Context, greeting *models.Greeting) error
+	DeleteGreeting(ctx context.Context, groupID, id int64) error
+	GetGreetings(ctx context.Context, groupID int64, lang string) ([]*models.Greeting, error)
+	GetRecentGreetings(ctx context.Context, groupID, birthdayID int64, limit int) ([]int64, error)
+	MarkGreetingUsed(ctx context.Context, groupID, birthdayID, greetingID int64) error
 
 	// Методы управления соединением
+	Ping(ctx context.Context) error
 	Close() error
 }
@@ -3,81 +3,93 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"Eldarius_bot/internal/calendar"
+	"Eldarius_bot/internal/clock"
 	"Eldarius_bot/internal/models"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+const (
+	// defaultNotifyTime время уведомлений для новых групп
+	defaultNotifyTime = "09:00"
+	// defaultTimezone часовой пояс групп, для которых он не задан
+	defaultTimezone = "Europe/Moscow"
+	// defaultLanguage язык групп, которые его не выбирали
+	defaultLanguage = "ru"
+	// maxReminderOffsets максимальное количество напоминаний об одном дне рождения
+	maxReminderOffsets = 10
+	// maxReminderDays максимальный срок напоминания в днях
+	maxReminderDays = 365
+	// maxBirthdays максимальное количество дней рождения в группе
+	maxBirthdays = 100
+	// maxGreetings максимальное количество собственных поздравлений группы
+	maxGreetings = 50
+)
+
+// DefaultReminderOffsets сроки напоминаний для групп, которые их не настраивали:
+// за неделю, накануне и в сам день рождения
+var DefaultReminderOffsets = []int{7, 1, 0}
+
+// Ошибки хранилища, которые показываются пользователю.
+// Обработчики сравнивают с ними через errors.Is, чтобы показать текст на языке группы.
+var (
+	ErrBirthdayNotFound = errors.New("день рождения не найден")
+	ErrBirthdayLimit    = errors.New("превышен лимит дней рождения в группе")
+	ErrTooManyReminders = errors.New("слишком много напоминаний")
+	ErrReminderRange    = errors.New("срок напоминания вне допустимого диапазона")
+	ErrEditorNotFound   = errors.New("редактор не найден")
+	ErrGreetingNotFound = errors.New("поздравление не найдено")
+	ErrGreetingLimit    = errors.New("превышен лимит поздравлений в группе")
+	ErrEmptyGreeting    = errors.New("поздравление не может быть пустым")
+)
+
 // SQLite реализует интерфейс Repository для SQLite
 type SQLite struct {
-	db *sql.DB
+	db     instrumentedDB
+	clock  clock.Clock
+	logger *slog.Logger
 }
 
-// NewSQLite создает новое подключение к SQLite
-func NewSQLite(dbPath string) (*SQLite, error) {
+// NewSQLite создает новое подключение к SQLite.
+// Часы clk определяют "сегодня" для ближайших дней рождения и отметки времени в записях.
+// В журнал logger записываются миграции схемы и, на уровне debug, запросы к базе.
+func NewSQLite(dbPath string, clk clock.Clock, logger *slog.Logger) (*SQLite, error) {
 	db, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка открытия базы данных: %w", err)
 	}
 
-	// Создаем таблицы, если они не существуют
-	if err := createTables(db); err != nil {
+	// Приводим схему к последней версии
+	if err := migrateTo(context.Background(), db, LatestSchemaVersion(), logger); err != nil {
 		db.Close()
 		return nil, err
 	}
 
-	return &SQLite{db: db}, nil
+	return &SQLite{db: instrumentedDB{DB: db, logger: logger}, clock: clk, logger: logger}, nil
 }
 
-// createTables создает необходимые таблицы в базе данных
-func createTables(db *sql.DB) error {
-	// Таблица групп
-	_, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS groups (
-			id INTEGER PRIMARY KEY,
-			title TEXT NOT NULL
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("ошибка создания таблицы групп: %w", err)
-	}
-
-	// Таблица дней рождения
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS birthdays (
-			id INTEGER PRIMARY KEY AUTOINCREMENT,
-			name TEXT NOT NULL,
-			birthday DATE NOT NULL,
-			group_id INTEGER NOT NULL,
-			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("ошибка создания таблицы дней рождения: %w", err)
-	}
-
-	// Таблица настроек
-	_, err = db.Exec(`
-		CREATE TABLE IF NOT EXISTS settings (
-			group_id INTEGER PRIMARY KEY,
-			notify_time TEXT NOT NULL,
-			FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE
-		)
-	`)
-	if err != nil {
-		return fmt.Errorf("ошибка создания таблицы настроек: %w", err)
-	}
+// Migrate приводит схему базы данных к указанной версии.
+// Используется для ручного отката; при запуске схема обновляется автоматически.
+func (s *SQLite) Migrate(ctx context.Context, version int) error {
+	return migrateTo(ctx, s.db.DB, version, s.logger)
+}
 
-	return nil
+// SchemaVersion возвращает текущую версию схемы базы данных
+func (s *SQLite) SchemaVersion(ctx context.Context) (int, error) {
+	return schemaVersion(ctx, s.db.DB)
 }
 
 // AddBirthday добавляет запись о дне рождения
 func (s *SQLite) AddBirthday(ctx context.Context, birthday *models.Birthday) error {
 	// Проверяем валидность записи
-	if err := birthday.Validate(); err != nil {
+	if err := birthday.Validate(s.clock.Now()); err != nil {
 		return fmt.Errorf("невалидная запись о дне рождения: %w", err)
 	}
 
@@ -105,15 +117,15 @@ func (s *SQLite) AddBirthday(ctx context.Context, birthday *models.Birthday) err
 		return fmt.Errorf("ошибка подсчета дней рождения: %w", err)
 	}
 
-	if count >= 100 {
-		return fmt.Errorf("превышен лимит дней рождения в группе (100)")
+	if count >= maxBirthdays {
+		return fmt.Errorf("%w (%d)", ErrBirthdayLimit, maxBirthdays)
 	}
 
 	// Добавляем день рождения
 	_, err = s.db.ExecContext(ctx, `
-		INSERT INTO birthdays (name, birthday, group_id)
-		VALUES (?, ?, ?)
-	`, birthday.Name, birthday.Birthday, birthday.GroupID)
+		INSERT INTO birthdays (name, birthday, year_unknown, group_id)
+		VALUES (?, ?, ?, ?)
+	`, birthday.Name, birthday.Birthday, birthday.YearUnknown, birthday.GroupID)
 	if err != nil {
 		return fmt.Errorf("ошибка добавления дня рождения: %w", err)
 	}
@@ -124,7 +136,7 @@ func (s *SQLite) AddBirthday(ctx context.Context, birthday *models.Birthday) err
 // GetBirthdays возвращает список дней рождения для группы
 func (s *SQLite) GetBirthdays(ctx context.Context, groupID int64) ([]*models.Birthday, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, birthday, group_id
+		SELECT id, name, birthday, year_unknown, group_id
 		FROM birthdays
 		WHERE group_id = ?
 		ORDER BY birthday
@@ -137,7 +149,7 @@ func (s *SQLite) GetBirthdays(ctx context.Context, groupID int64) ([]*models.Bir
 	var birthdays []*models.Birthday
 	for rows.Next() {
 		b := &models.Birthday{}
-		err := rows.Scan(&b.ID, &b.Name, &b.Birthday, &b.GroupID)
+		err := rows.Scan(&b.ID, &b.Name, &b.Birthday, &b.YearUnknown, &b.GroupID)
 		if err != nil {
 			return nil, fmt.Errorf("ошибка сканирования дня рождения: %w", err)
 		}
@@ -151,6 +163,24 @@ func (s *SQLite) GetBirthdays(ctx context.Context, groupID int64) ([]*models.Bir
 	return birthdays, nil
 }
 
+// GetBirthday возвращает запись о дне рождения группы по ID
+func (s *SQLite) GetBirthday(ctx context.Context, groupID int64, id int64) (*models.Birthday, error) {
+	b := &models.Birthday{}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, name, birthday, year_unknown, group_id
+		FROM birthdays
+		WHERE id = ? AND group_id = ?
+	`, id, groupID).Scan(&b.ID, &b.Name, &b.Birthday, &b.YearUnknown, &b.GroupID)
+	if err == sql.ErrNoRows {
+		return nil, ErrBirthdayNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения дня рождения: %w", err)
+	}
+
+	return b, nil
+}
+
 // DeleteBirthday удаляет запись о дне рождения
 func (s *SQLite) DeleteBirthday(ctx context.Context, groupID int64, id int64) error {
 	result, err := s.db.ExecContext(ctx, `
@@ -167,46 +197,64 @@ func (s *SQLite) DeleteBirthday(ctx context.Context, groupID int64, id int64) er
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("день рождения не найден")
+		return ErrBirthdayNotFound
+	}
+
+	return nil
+}
+
+// UpdateBirthday изменяет имя и дату существующей записи о дне рождения
+func (s *SQLite) UpdateBirthday(ctx context.Context, birthday *models.Birthday) error {
+	if err := birthday.Validate(s.clock.Now()); err != nil {
+		return fmt.Errorf("невалидная запись о дне рождения: %w", err)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE birthdays
+		SET name = ?, birthday = ?, year_unknown = ?
+		WHERE id = ? AND group_id = ?
+	`, birthday.Name, birthday.Birthday, birthday.YearUnknown, birthday.ID, birthday.GroupID)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения дня рождения: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка получения количества измененных строк: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrBirthdayNotFound
 	}
 
 	return nil
 }
 
-// GetUpcomingBirthdays возвращает список предстоящих дней рождения
+// GetUpcomingBirthdays возвращает дни рождения, наступающие в ближайшие days дней,
+// упорядоченные от ближайшего. Даты считаются в Go пакетом calendar, а не
+// сравнением строк в SQL, чтобы корректно обрабатывать переход через год и 29 февраля.
 func (s *SQLite) GetUpcomingBirthdays(ctx context.Context, groupID int64, days int) ([]*models.Birthday, error) {
-	if days <= 0 {
-		return nil, fmt.Errorf("количество дней должно быть положительным")
+	if days < 0 {
+		return nil, fmt.Errorf("количество дней не может быть отрицательным")
 	}
 
-	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, name, birthday, group_id
-		FROM birthdays
-		WHERE group_id = ?
-		AND strftime('%m-%d', birthday) BETWEEN strftime('%m-%d', 'now')
-		AND strftime('%m-%d', 'now', '+' || ? || ' days')
-		ORDER BY strftime('%m-%d', birthday)
-	`, groupID, days)
+	// "Сегодня" определяется в часовом поясе группы
+	loc, err := s.GetTimezone(ctx, groupID)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка получения предстоящих дней рождения: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	var birthdays []*models.Birthday
-	for rows.Next() {
-		b := &models.Birthday{}
-		err := rows.Scan(&b.ID, &b.Name, &b.Birthday, &b.GroupID)
-		if err != nil {
-			return nil, fmt.Errorf("ошибка сканирования дня рождения: %w", err)
-		}
-		birthdays = append(birthdays, b)
+	birthdays, err := s.GetBirthdays(ctx, groupID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("ошибка при чтении дней рождения: %w", err)
+	var upcoming []*models.Birthday
+	for _, o := range calendar.Upcoming(birthdays, s.clock.Now().In(loc), days) {
+		upcoming = append(upcoming, o.Birthday)
 	}
 
-	return birthdays, nil
+	return upcoming, nil
 }
 
 // AddGroup добавляет группу
@@ -226,6 +274,47 @@ func (s *SQLite) AddGroup(ctx context.Context, group *models.Group) error {
 	return nil
 }
 
+// EnsureGroup регистрирует группу и создает для нее настройки по умолчанию,
+// если группа еще не известна. Пустое название не затирает сохраненное.
+// Сообщение из группы означает, что бот в ней состоит, поэтому группа снова становится активной.
+func (s *SQLite) EnsureGroup(ctx context.Context, group *models.Group) error {
+	if group.ID == 0 {
+		return fmt.Errorf("ID группы не может быть пустым")
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO groups (id, title)
+		VALUES (?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			title = CASE WHEN excluded.title != '' THEN excluded.title ELSE groups.title END,
+			active = 1
+		WHERE (excluded.title != '' AND groups.title != excluded.title) OR groups.active = 0
+	`, group.ID, group.Title)
+	if err != nil {
+		return fmt.Errorf("ошибка регистрации группы: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO settings (group_id, notify_time)
+		VALUES (?, ?)
+	`, group.ID, defaultNotifyTime)
+	if err != nil {
+		return fmt.Errorf("ошибка создания настроек группы: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return nil
+}
+
 // GetGroup возвращает информацию о группе
 func (s *SQLite) GetGroup(ctx context.Context, id int64) (*models.Group, error) {
 	group := &models.Group{ID: id}
@@ -242,10 +331,10 @@ func (s *SQLite) GetGroup(ctx context.Context, id int64) (*models.Group, error)
 	return group, nil
 }
 
-// GetAllGroups возвращает список всех групп
+// GetAllGroups возвращает список групп, в которых состоит бот
 func (s *SQLite) GetAllGroups(ctx context.Context) ([]*models.Group, error) {
 	rows, err := s.db.QueryContext(ctx, `
-		SELECT id, title FROM groups
+		SELECT id, title FROM groups WHERE active = 1
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка получения групп: %w", err)
@@ -269,6 +358,70 @@ func (s *SQLite) GetAllGroups(ctx context.Context) ([]*models.Group, error) {
 	return groups, nil
 }
 
+// SetGroupActive отмечает, состоит ли бот в группе. Неактивные группы не возвращает GetAllGroups,
+// но их дни рождения и настройки сохраняются до возвращения бота.
+func (s *SQLite) SetGroupActive(ctx context.Context, groupID int64, active bool) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE groups SET active = ? WHERE id = ?
+	`, active, groupID)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения активности группы: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateGroup переносит группу oldID на newID, когда Telegram превращает группу в супергруппу.
+// Все данные группы переносятся в одной транзакции. Настройки, сроки напоминаний и шаблоны
+// старой группы заменяют те, что успели создаться для новой, а дни рождения, поздравления
+// и редакторы объединяются.
+func (s *SQLite) MigrateGroup(ctx context.Context, oldID, newID int64) error {
+	if oldID == 0 || newID == 0 || oldID == newID {
+		return fmt.Errorf("некорректный перенос группы %d в %d", oldID, newID)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Параметр ?1 — новая группа, ?2 — старая
+	statements := []string{
+		// Новая группа наследует название старой и активна: бот уже состоит в ней
+		`INSERT INTO groups (id, title, active)
+		SELECT ?1, title, 1 FROM groups WHERE id = ?2
+		ON CONFLICT(id) DO UPDATE SET active = 1`,
+		`UPDATE OR REPLACE settings SET group_id = ?1 WHERE group_id = ?2`,
+		`UPDATE OR REPLACE message_templates SET group_id = ?1 WHERE group_id = ?2`,
+		`DELETE FROM reminder_offsets
+		WHERE group_id = ?1 AND EXISTS (SELECT 1 FROM reminder_offsets WHERE group_id = ?2)`,
+		`UPDATE reminder_offsets SET group_id = ?1 WHERE group_id = ?2`,
+		`UPDATE birthdays SET group_id = ?1 WHERE group_id = ?2`,
+		`UPDATE greetings SET group_id = ?1 WHERE group_id = ?2`,
+		`UPDATE greeting_history SET group_id = ?1 WHERE group_id = ?2`,
+		// Совпадающие записи у новой группы уже есть, остатки старой удаляются ниже
+		`UPDATE OR IGNORE notifications_sent SET group_id = ?1 WHERE group_id = ?2`,
+		`UPDATE OR IGNORE group_editors SET group_id = ?1 WHERE group_id = ?2`,
+		`UPDATE OR IGNORE conversations SET chat_id = ?1 WHERE chat_id = ?2`,
+		`DELETE FROM notifications_sent WHERE group_id = ?2`,
+		`DELETE FROM group_editors WHERE group_id = ?2`,
+		`DELETE FROM conversations WHERE chat_id = ?2`,
+		`DELETE FROM groups WHERE id = ?2`,
+	}
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt, newID, oldID); err != nil {
+			return fmt.Errorf("ошибка переноса группы: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return nil
+}
+
 // GetNotifyTime возвращает время уведомления для группы
 func (s *SQLite) GetNotifyTime(ctx context.Context, groupID int64) (time.Time, error) {
 	var timeStr string
@@ -295,8 +448,9 @@ func (s *SQLite) GetNotifyTime(ctx context.Context, groupID int64) (time.Time, e
 func (s *SQLite) SetNotifyTime(ctx context.Context, groupID int64, t time.Time) error {
 	timeStr := t.Format("15:04")
 	_, err := s.db.ExecContext(ctx, `
-		INSERT OR REPLACE INTO settings (group_id, notify_time)
+		INSERT INTO settings (group_id, notify_time)
 		VALUES (?, ?)
+		ON CONFLICT(group_id) DO UPDATE SET notify_time = excluded.notify_time
 	`, groupID, timeStr)
 	if err != nil {
 		return fmt.Errorf("ошибка установки времени уведомления: %w", err)
@@ -305,6 +459,493 @@ func (s *SQLite) SetNotifyTime(ctx context.Context, groupID int64, t time.Time)
 	return nil
 }
 
+// GetTimezone возвращает часовой пояс группы
+func (s *SQLite) GetTimezone(ctx context.Context, groupID int64) (*time.Location, error) {
+	name := defaultTimezone
+	err := s.db.QueryRowContext(ctx, `
+		SELECT timezone FROM settings WHERE group_id = ?
+	`, groupID).Scan(&name)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("ошибка получения часового пояса: %w", err)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки часового пояса %s: %w", name, err)
+	}
+
+	return loc, nil
+}
+
+// SetTimezone устанавливает часовой пояс группы
+func (s *SQLite) SetTimezone(ctx context.Context, groupID int64, loc *time.Location) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO settings (group_id, notify_time, timezone)
+		VALUES (?, ?, ?)
+		ON CONFLICT(group_id) DO UPDATE SET timezone = excluded.timezone
+	`, groupID, defaultNotifyTime, loc.String())
+	if err != nil {
+		return fmt.Errorf("ошибка установки часового пояса: %w", err)
+	}
+
+	return nil
+}
+
+// GetLanguage возвращает код языка группы
+func (s *SQLite) GetLanguage(ctx context.Context, groupID int64) (string, error) {
+	var lang string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT language FROM settings WHERE group_id = ?
+	`, groupID).Scan(&lang)
+	if err == sql.ErrNoRows {
+		return defaultLanguage, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения языка: %w", err)
+	}
+
+	return lang, nil
+}
+
+// SetLanguage устанавливает код языка группы
+func (s *SQLite) SetLanguage(ctx context.Context, groupID int64, lang string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO settings (group_id, notify_time, language)
+		VALUES (?, ?, ?)
+		ON CONFLICT(group_id) DO UPDATE SET language = excluded.language
+	`, groupID, defaultNotifyTime, lang)
+	if err != nil {
+		return fmt.Errorf("ошибка установки языка: %w", err)
+	}
+
+	return nil
+}
+
+// GetReminderOffsets возвращает сроки напоминаний группы в днях по убыванию
+func (s *SQLite) GetReminderOffsets(ctx context.Context, groupID int64) ([]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT days_before FROM reminder_offsets
+		WHERE group_id = ?
+		ORDER BY days_before DESC
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сроков напоминаний: %w", err)
+	}
+	defer rows.Close()
+
+	var offsets []int
+	for rows.Next() {
+		var days int
+		if err := rows.Scan(&days); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования срока напоминания: %w", err)
+		}
+		offsets = append(offsets, days)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении сроков напоминаний: %w", err)
+	}
+
+	if len(offsets) == 0 {
+		return append([]int(nil), DefaultReminderOffsets...), nil
+	}
+
+	return offsets, nil
+}
+
+// SetReminderOffsets заменяет сроки напоминаний группы.
+// Пустой список возвращает группе сроки по умолчанию.
+func (s *SQLite) SetReminderOffsets(ctx context.Context, groupID int64, offsets []int) error {
+	if len(offsets) > maxReminderOffsets {
+		return fmt.Errorf("%w (максимум %d)", ErrTooManyReminders, maxReminderOffsets)
+	}
+	for _, days := range offsets {
+		if days < 0 || days > maxReminderDays {
+			return fmt.Errorf("%w: должен быть от 0 до %d дней", ErrReminderRange, maxReminderDays)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка начала транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM reminder_offsets WHERE group_id = ?
+	`, groupID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления сроков напоминаний: %w", err)
+	}
+
+	for _, days := range offsets {
+		_, err = tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO reminder_offsets (group_id, days_before)
+			VALUES (?, ?)
+		`, groupID, days)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения срока напоминания: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка фиксации транзакции: %w", err)
+	}
+
+	return nil
+}
+
+// IsNotificationSent проверяет, отправлялось ли уведомление о дне рождения,
+// наступающем в дату occurrence, за daysBefore дней
+func (s *SQLite) IsNotificationSent(ctx context.Context, groupID, birthdayID int64, occurrence time.Time, daysBefore int) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM notifications_sent
+			WHERE group_id = ? AND birthday_id = ? AND occurrence = ? AND days_before = ?
+		)
+	`, groupID, birthdayID, occurrence.Format("2006-01-02"), daysBefore).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки журнала уведомлений: %w", err)
+	}
+
+	return exists, nil
+}
+
+// MarkNotificationSent записывает уведомление в журнал отправленных
+func (s *SQLite) MarkNotificationSent(ctx context.Context, groupID, birthdayID int64, occurrence time.Time, daysBefore int) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT OR IGNORE INTO notifications_sent (group_id, birthday_id, occurrence, days_before, sent_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, groupID, birthdayID, occurrence.Format("2006-01-02"), daysBefore, s.clock.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("ошибка записи в журнал уведомлений: %w", err)
+	}
+
+	return nil
+}
+
+// AddEditor разрешает пользователю изменять дни рождения и настройки группы
+func (s *SQLite) AddEditor(ctx context.Context, editor *models.Editor) error {
+	if editor.GroupID == 0 || editor.UserID == 0 {
+		return fmt.Errorf("не указана группа или пользователь")
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO group_editors (group_id, user_id, name)
+		VALUES (?, ?, ?)
+		ON CONFLICT(group_id, user_id) DO UPDATE SET name = excluded.name
+	`, editor.GroupID, editor.UserID, editor.Name)
+	if err != nil {
+		return fmt.Errorf("ошибка добавления редактора: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveEditor отзывает у пользователя право изменять данные группы
+func (s *SQLite) RemoveEditor(ctx context.Context, groupID, userID int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM group_editors WHERE group_id = ? AND user_id = ?
+	`, groupID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления редактора: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка получения количества удаленных строк: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrEditorNotFound
+	}
+
+	return nil
+}
+
+// GetEditors возвращает дополнительных редакторов группы
+func (s *SQLite) GetEditors(ctx context.Context, groupID int64) ([]*models.Editor, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT group_id, user_id, name
+		FROM group_editors
+		WHERE group_id = ?
+		ORDER BY name
+	`, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения редакторов: %w", err)
+	}
+	defer rows.Close()
+
+	var editors []*models.Editor
+	for rows.Next() {
+		e := &models.Editor{}
+		if err := rows.Scan(&e.GroupID, &e.UserID, &e.Name); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования редактора: %w", err)
+		}
+		editors = append(editors, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении редакторов: %w", err)
+	}
+
+	return editors, nil
+}
+
+// IsEditor проверяет, входит ли пользователь в список редакторов группы
+func (s *SQLite) IsEditor(ctx context.Context, groupID, userID int64) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM group_editors WHERE group_id = ? AND user_id = ?)
+	`, groupID, userID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("ошибка проверки редактора: %w", err)
+	}
+
+	return exists, nil
+}
+
+// GetConversation возвращает состояние диалога пользователя в чате или nil, если диалога нет
+func (s *SQLite) GetConversation(ctx context.Context, chatID, userID int64) (*models.Conversation, error) {
+	conv := &models.Conversation{ChatID: chatID, UserID: userID}
+	var data string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT flow, step, data, updated_at
+		FROM conversations
+		WHERE chat_id = ? AND user_id = ?
+	`, chatID, userID).Scan(&conv.Flow, &conv.Step, &data, &conv.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения состояния диалога: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(data), &conv.Data); err != nil {
+		return nil, fmt.Errorf("ошибка разбора данных диалога: %w", err)
+	}
+
+	return conv, nil
+}
+
+// SaveConversation сохраняет состояние диалога и отмечает время последнего шага
+func (s *SQLite) SaveConversation(ctx context.Context, conv *models.Conversation) error {
+	data, err := json.Marshal(conv.Data)
+	if err != nil {
+		return fmt.Errorf("ошибка сериализации данных диалога: %w", err)
+	}
+
+	conv.UpdatedAt = s.clock.Now().UTC()
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO conversations (chat_id, user_id, flow, step, data, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(chat_id, user_id) DO UPDATE SET
+			flow = excluded.flow,
+			step = excluded.step,
+			data = excluded.data,
+			updated_at = excluded.updated_at
+	`, conv.ChatID, conv.UserID, conv.Flow, conv.Step, string(data), conv.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения состояния диалога: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteConversation завершает диалог пользователя в чате
+func (s *SQLite) DeleteConversation(ctx context.Context, chatID, userID int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM conversations WHERE chat_id = ? AND user_id = ?
+	`, chatID, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления состояния диалога: %w", err)
+	}
+
+	return nil
+}
+
+// GetTemplate возвращает шаблон уведомления группы или пустую строку, если группа его не задавала
+func (s *SQLite) GetTemplate(ctx context.Context, groupID int64, kind string) (string, error) {
+	var body string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT body FROM message_templates WHERE group_id = ? AND kind = ?
+	`, groupID, kind).Scan(&body)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения шаблона: %w", err)
+	}
+
+	return body, nil
+}
+
+// SetTemplate сохраняет шаблон уведомления группы.
+// Шаблон должен быть проверен до сохранения.
+func (s *SQLite) SetTemplate(ctx context.Context, groupID int64, kind, body string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO message_templates (group_id, kind, body)
+		VALUES (?, ?, ?)
+		ON CONFLICT(group_id, kind) DO UPDATE SET body = excluded.body
+	`, groupID, kind, body)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения шаблона: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteTemplate удаляет шаблон группы, возвращая шаблон по умолчанию
+func (s *SQLite) DeleteTemplate(ctx context.Context, groupID int64, kind string) error {
+	_, err := s.db.ExecContext(ctx, `
+		DELETE FROM message_templates WHERE group_id = ? AND kind = ?
+	`, groupID, kind)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления шаблона: %w", err)
+	}
+
+	return nil
+}
+
+// AddGreeting добавляет поздравление в библиотеку группы
+func (s *SQLite) AddGreeting(ctx context.Context, greeting *models.Greeting) error {
+	if greeting.GroupID == 0 {
+		return fmt.Errorf("не указана группа")
+	}
+	if greeting.Text == "" && greeting.FileID == "" {
+		return ErrEmptyGreeting
+	}
+
+	var count int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM greetings WHERE group_id = ?
+	`, greeting.GroupID).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("ошибка подсчета поздравлений: %w", err)
+	}
+
+	if count >= maxGreetings {
+		return fmt.Errorf("%w (%d)", ErrGreetingLimit, maxGreetings)
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO greetings (group_id, text, media_type, file_id)
+		VALUES (?, ?, ?, ?)
+	`, greeting.GroupID, greeting.Text, greeting.MediaType, greeting.FileID)
+	if err != nil {
+		return fmt.Errorf("ошибка добавления поздравления: %w", err)
+	}
+
+	greeting.ID, err = result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("ошибка получения ID поздравления: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteGreeting удаляет поздравление группы. Встроенные поздравления удалить нельзя.
+func (s *SQLite) DeleteGreeting(ctx context.Context, groupID, id int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		DELETE FROM greetings WHERE id = ? AND group_id = ?
+	`, id, groupID)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления поздравления: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка получения количества удаленных строк: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrGreetingNotFound
+	}
+
+	return nil
+}
+
+// GetGreetings возвращает встроенные поздравления на языке lang и поздравления группы
+func (s *SQLite) GetGreetings(ctx context.Context, groupID int64, lang string) ([]*models.Greeting, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, group_id, text, media_type, file_id
+		FROM greetings
+		WHERE (group_id = 0 AND lang = ?) OR group_id = ?
+		ORDER BY id
+	`, lang, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения поздравлений: %w", err)
+	}
+	defer rows.Close()
+
+	var greetings []*models.Greeting
+	for rows.Next() {
+		g := &models.Greeting{}
+		if err := rows.Scan(&g.ID, &g.GroupID, &g.Text, &g.MediaType, &g.FileID); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования поздравления: %w", err)
+		}
+		greetings = append(greetings, g)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении поздравлений: %w", err)
+	}
+
+	return greetings, nil
+}
+
+// GetRecentGreetings возвращает ID последних limit поздравлений, отправленных имениннику
+func (s *SQLite) GetRecentGreetings(ctx context.Context, groupID, birthdayID int64, limit int) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT greeting_id
+		FROM greeting_history
+		WHERE group_id = ? AND birthday_id = ?
+		ORDER BY used_at DESC
+		LIMIT ?
+	`, groupID, birthdayID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения истории поздравлений: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ошибка сканирования истории поздравлений: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении истории поздравлений: %w", err)
+	}
+
+	return ids, nil
+}
+
+// MarkGreetingUsed записывает поздравление в историю именинника
+func (s *SQLite) MarkGreetingUsed(ctx context.Context, groupID, birthdayID, greetingID int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO greeting_history (group_id, birthday_id, greeting_id, used_at)
+		VALUES (?, ?, ?, ?)
+	`, groupID, birthdayID, greetingID, s.clock.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("ошибка записи истории поздравлений: %w", err)
+	}
+
+	return nil
+}
+
+// Ping проверяет, что база данных доступна
+func (s *SQLite) Ping(ctx context.Context) error {
+	if err := s.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("ошибка проверки соединения с базой данных: %w", err)
+	}
+	return nil
+}
+
 // Close закрывает соединение с базой данных
 func (s *SQLite) Close() error {
 	return s.db.Close()
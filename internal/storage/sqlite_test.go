@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"Eldarius_bot/internal/models"
+)
+
+func TestMigrateGroup(t *testing.T) {
+	const (
+		oldID int64 = -2101
+		newID int64 = -1002101
+	)
+
+	store := openSQLite(t, filepath.Join(t.TempDir(), "birthdays.db"))
+	ctx := context.Background()
+	occurrence := time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	// must прерывает тест при ошибке подготовки данных
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("ошибка подготовки данных: %v", err)
+		}
+	}
+	// addBirthday добавляет день рождения и возвращает его ID
+	addBirthday := func(groupID int64, name string) int64 {
+		t.Helper()
+		b := &models.Birthday{Name: name, Birthday: time.Date(1990, time.March, 15, 0, 0, 0, 0, time.UTC), GroupID: groupID}
+		must(store.AddBirthday(ctx, b))
+		birthdays, err := store.GetBirthdays(ctx, groupID)
+		must(err)
+		for _, b := range birthdays {
+			if b.Name == name {
+				return b.ID
+			}
+		}
+		t.Fatalf("день рождения %s не найден", name)
+		return 0
+	}
+	notifyAt := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse("15:04", s)
+		must(err)
+		return v
+	}
+
+	// Старая группа со всеми данными
+	must(store.EnsureGroup(ctx, &models.Group{ID: oldID, Title: "Семья"}))
+	must(store.SetNotifyTime(ctx, oldID, notifyAt("08:00")))
+	must(store.SetLanguage(ctx, oldID, "en"))
+	must(store.SetReminderOffsets(ctx, oldID, []int{3}))
+	must(store.SetTemplate(ctx, oldID, "birthday", "старый шаблон"))
+	oldBirthday := addBirthday(oldID, "Иван Петров")
+	must(store.MarkNotificationSent(ctx, oldID, oldBirthday, occurrence, 3))
+	must(store.AddEditor(ctx, &models.Editor{GroupID: oldID, UserID: 10, Name: "Редактор старой"}))
+	must(store.AddEditor(ctx, &models.Editor{GroupID: oldID, UserID: 11, Name: "Только в старой"}))
+	greeting := &models.Greeting{GroupID: oldID, Text: "С праздником!"}
+	must(store.AddGreeting(ctx, greeting))
+	must(store.MarkGreetingUsed(ctx, oldID, oldBirthday, greeting.ID))
+	must(store.SaveConversation(ctx, &models.Conversation{ChatID: oldID, UserID: 5, Flow: "add", Step: "старый"}))
+	must(store.SaveConversation(ctx, &models.Conversation{ChatID: oldID, UserID: 7, Flow: "add", Step: "старый"}))
+
+	// Супергруппа, в которой данные успели появиться до переноса
+	must(store.EnsureGroup(ctx, &models.Group{ID: newID, Title: "Семья (супергруппа)"}))
+	must(store.SetNotifyTime(ctx, newID, notifyAt("10:00")))
+	must(store.SetReminderOffsets(ctx, newID, []int{1}))
+	must(store.SetTemplate(ctx, newID, "birthday", "новый шаблон"))
+	must(store.SetTemplate(ctx, newID, "reminder", "напоминание супергруппы"))
+	newBirthday := addBirthday(newID, "Анна Петрова")
+	must(store.MarkNotificationSent(ctx, newID, newBirthday, occurrence, 0))
+	// Совпадает с записью старой группы
+	must(store.MarkNotificationSent(ctx, newID, oldBirthday, occurrence, 3))
+	must(store.AddEditor(ctx, &models.Editor{GroupID: newID, UserID: 10, Name: "Редактор новой"}))
+	must(store.AddEditor(ctx, &models.Editor{GroupID: newID, UserID: 12, Name: "Только в новой"}))
+	must(store.SaveConversation(ctx, &models.Conversation{ChatID: newID, UserID: 5, Flow: "edit", Step: "новый"}))
+
+	if err := store.MigrateGroup(ctx, oldID, newID); err != nil {
+		t.Fatalf("ошибка переноса группы: %v", err)
+	}
+
+	// Старой группы больше нет, новая активна и сохраняет свое название
+	if _, err := store.GetGroup(ctx, oldID); err == nil {
+		t.Error("старая группа осталась в хранилище")
+	}
+	group, err := store.GetGroup(ctx, newID)
+	if err != nil {
+		t.Fatalf("ошибка получения новой группы: %v", err)
+	}
+	if group.Title != "Семья (супергруппа)" {
+		t.Errorf("название новой группы = %q", group.Title)
+	}
+	groups, err := store.GetAllGroups(ctx)
+	if err != nil {
+		t.Fatalf("ошибка получения групп: %v", err)
+	}
+	if len(groups) != 1 || groups[0].ID != newID {
+		t.Errorf("активные группы = %v, ожидалась только %d", groups, newID)
+	}
+
+	// Настройки, сроки напоминаний и шаблоны старой группы заменяют настройки новой
+	notifyTime, err := store.GetNotifyTime(ctx, newID)
+	if err != nil {
+		t.Fatalf("ошибка получения времени уведомления: %v", err)
+	}
+	if got := notifyTime.Format("15:04"); got != "08:00" {
+		t.Errorf("время уведомления = %s, ожидалось 08:00 старой группы", got)
+	}
+	if lang, err := store.GetLanguage(ctx, newID); err != nil || lang != "en" {
+		t.Errorf("язык = %q (%v), ожидался en старой группы", lang, err)
+	}
+	if offsets, err := store.GetReminderOffsets(ctx, newID); err != nil || !slices.Equal(offsets, []int{3}) {
+		t.Errorf("сроки напоминаний = %v (%v), ожидались [3] старой группы", offsets, err)
+	}
+	if body, err := store.GetTemplate(ctx, newID, "birthday"); err != nil || body != "старый шаблон" {
+		t.Errorf("шаблон поздравления = %q (%v), ожидался шаблон старой группы", body, err)
+	}
+	if body, err := store.GetTemplate(ctx, newID, "reminder"); err != nil || body != "напоминание супергруппы" {
+		t.Errorf("шаблон напоминания = %q (%v), шаблон без пары в старой группе потерян", body, err)
+	}
+
+	// Дни рождения, поздравления и журнал уведомлений объединяются
+	names := birthdayNames(t, store, newID)
+	if names[oldBirthday] != "Иван Петров" || names[newBirthday] != "Анна Петрова" {
+		t.Errorf("дни рождения новой группы = %v", names)
+	}
+	for _, n := range []struct {
+		birthdayID int64
+		daysBefore int
+	}{{oldBirthday, 3}, {newBirthday, 0}} {
+		sent, err := store.IsNotificationSent(ctx, newID, n.birthdayID, occurrence, n.daysBefore)
+		if err != nil || !sent {
+			t.Errorf("уведомление о %d за %d дней не найдено в журнале новой группы (%v)", n.birthdayID, n.daysBefore, err)
+		}
+	}
+	if sent, _ := store.IsNotificationSent(ctx, oldID, oldBirthday, occurrence, 3); sent {
+		t.Error("журнал уведомлений старой группы не удален")
+	}
+	greetings, err := store.GetGreetings(ctx, newID, "en")
+	if err != nil {
+		t.Fatalf("ошибка получения поздравлений: %v", err)
+	}
+	if !slices.ContainsFunc(greetings, func(g *models.Greeting) bool { return g.ID == greeting.ID && g.GroupID == newID }) {
+		t.Error("поздравление старой группы не перенесено")
+	}
+	if recent, err := store.GetRecentGreetings(ctx, newID, oldBirthday, 5); err != nil || !slices.Equal(recent, []int64{greeting.ID}) {
+		t.Errorf("история поздравлений = %v (%v)", recent, err)
+	}
+
+	// Редакторы объединяются, при совпадении остается запись новой группы
+	editors, err := store.GetEditors(ctx, newID)
+	if err != nil {
+		t.Fatalf("ошибка получения редакторов: %v", err)
+	}
+	gotEditors := make(map[int64]string)
+	for _, e := range editors {
+		gotEditors[e.UserID] = e.Name
+	}
+	wantEditors := map[int64]string{10: "Редактор новой", 11: "Только в старой", 12: "Только в новой"}
+	if len(gotEditors) != len(wantEditors) {
+		t.Errorf("редакторы новой группы = %v, ожидались %v", gotEditors, wantEditors)
+	}
+	for id, name := range wantEditors {
+		if gotEditors[id] != name {
+			t.Errorf("редактор %d = %q, ожидался %q", id, gotEditors[id], name)
+		}
+	}
+	if old, err := store.GetEditors(ctx, oldID); err != nil || len(old) != 0 {
+		t.Errorf("у старой группы остались редакторы: %v (%v)", old, err)
+	}
+
+	// Диалоги переносятся, при совпадении остается диалог, начатый уже в новой группе
+	for _, c := range []struct {
+		userID   int64
+		wantStep string
+	}{{5, "новый"}, {7, "старый"}} {
+		conv, err := store.GetConversation(ctx, newID, c.userID)
+		if err != nil || conv == nil || conv.Step != c.wantStep {
+			t.Errorf("диалог пользователя %d = %+v (%v), ожидался шаг %q", c.userID, conv, err, c.wantStep)
+		}
+		if conv, _ := store.GetConversation(ctx, oldID, c.userID); conv != nil {
+			t.Errorf("диалог пользователя %d остался в старой группе", c.userID)
+		}
+	}
+}
+
+func TestMigrateGroupRejectsInvalidIDs(t *testing.T) {
+	store := openSQLite(t, filepath.Join(t.TempDir(), "birthdays.db"))
+
+	for _, ids := range [][2]int64{{0, -100}, {-1, 0}, {-1, -1}} {
+		if err := store.MigrateGroup(context.Background(), ids[0], ids[1]); err == nil {
+			t.Errorf("MigrateGroup(%d, %d) без ошибки", ids[0], ids[1])
+		}
+	}
+}
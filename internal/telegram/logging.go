@@ -0,0 +1,128 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrorAttrs возвращает атрибуты журнала для ошибки Bot API: код и описание Telegram,
+// а для ошибки 429 — через сколько секунд можно повторить запрос
+func ErrorAttrs(err error) []any {
+	attrs := []any{slog.Any("error", err)}
+
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) {
+		attrs = append(attrs, slog.Int("telegram_code", apiErr.Code))
+		if apiErr.RetryAfter > 0 {
+			attrs = append(attrs, slog.Int("retry_after", apiErr.RetryAfter))
+		}
+	}
+	return attrs
+}
+
+// loggingClient Client, записывающий в журнал каждый неудачный запрос к Bot API
+type loggingClient struct {
+	Client
+	logger *slog.Logger
+}
+
+// WithLogging оборачивает client так, что каждая ошибка Bot API записывается в журнал
+// с методом, чатом и кодом ошибки Telegram. Ошибка по-прежнему возвращается вызывающему.
+func WithLogging(client Client, logger *slog.Logger) Client {
+	return &loggingClient{Client: client, logger: logger}
+}
+
+// Send отправляет сообщение и записывает ошибку в журнал
+func (c *loggingClient) Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	message, err := c.Client.Send(chattable)
+	if err != nil {
+		c.logFailure(chattable, err)
+	}
+	return message, err
+}
+
+// Request выполняет запрос и записывает ошибку в журнал
+func (c *loggingClient) Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	resp, err := c.Client.Request(chattable)
+	if err != nil {
+		c.logFailure(chattable, err)
+	}
+	return resp, err
+}
+
+// GetChatAdministrators возвращает администраторов чата и записывает ошибку в журнал
+func (c *loggingClient) GetChatAdministrators(config tgbotapi.ChatAdministratorsConfig) ([]tgbotapi.ChatMember, error) {
+	members, err := c.Client.GetChatAdministrators(config)
+	if err != nil {
+		c.logger.Warn("Ошибка Bot API", append([]any{
+			slog.String("method", "getChatAdministrators"),
+			slog.Int64("chat_id", config.ChatID),
+		}, ErrorAttrs(err)...)...)
+	}
+	return members, err
+}
+
+// MakeRequest вызывает метод Bot API и записывает ошибку в журнал
+func (c *loggingClient) MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error) {
+	resp, err := c.Client.MakeRequest(endpoint, params)
+	if err != nil {
+		c.logger.Warn("Ошибка Bot API", append([]any{slog.String("method", endpoint)}, ErrorAttrs(err)...)...)
+	}
+	return resp, err
+}
+
+// logFailure записывает в журнал неудачный вызов с запросом chattable
+func (c *loggingClient) logFailure(chattable tgbotapi.Chattable, err error) {
+	method, chatID := describe(chattable)
+	attrs := []any{slog.String("method", method)}
+	if chatID != 0 {
+		attrs = append(attrs, slog.Int64("chat_id", chatID))
+	}
+	c.logger.Warn("Ошибка Bot API", append(attrs, ErrorAttrs(err)...)...)
+}
+
+// describe возвращает метод Bot API запроса и чат, которому он адресован, если их можно определить
+func describe(chattable tgbotapi.Chattable) (string, int64) {
+	switch c := chattable.(type) {
+	case tgbotapi.MessageConfig:
+		return "sendMessage", c.ChatID
+	case tgbotapi.StickerConfig:
+		return "sendSticker", c.ChatID
+	case tgbotapi.AnimationConfig:
+		return "sendAnimation", c.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return "editMessageText", c.ChatID
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return "editMessageReplyMarkup", c.ChatID
+	case tgbotapi.CallbackConfig:
+		return "answerCallbackQuery", 0
+	case tgbotapi.DeleteWebhookConfig:
+		return "deleteWebhook", 0
+	default:
+		return fmt.Sprintf("%T", chattable), 0
+	}
+}
+
+// libraryLogger направляет журнал библиотеки telegram-bot-api в slog
+type libraryLogger struct {
+	logger *slog.Logger
+}
+
+// Println записывает сообщение библиотеки
+func (l libraryLogger) Println(v ...interface{}) {
+	l.logger.Warn(strings.TrimSpace(fmt.Sprintln(v...)), slog.String("source", "telegram-bot-api"))
+}
+
+// Printf записывает сообщение библиотеки
+func (l libraryLogger) Printf(format string, v ...interface{}) {
+	l.logger.Warn(strings.TrimSpace(fmt.Sprintf(format, v...)), slog.String("source", "telegram-bot-api"))
+}
+
+// SetLibraryLogger направляет сообщения библиотеки telegram-bot-api, например об ошибках getUpdates, в logger
+func SetLibraryLogger(logger *slog.Logger) {
+	_ = tgbotapi.SetLogger(libraryLogger{logger: logger})
+}
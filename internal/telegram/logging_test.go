@@ -0,0 +1,58 @@
+package telegram_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"Eldarius_bot/internal/logging"
+	"Eldarius_bot/internal/telegram"
+	"Eldarius_bot/internal/telegram/telegramtest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestWithLoggingRecordsTelegramCode(t *testing.T) {
+	srv := telegramtest.NewServer()
+	defer srv.Close()
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(telegramtest.Token, srv.Endpoint())
+	if err != nil {
+		t.Fatalf("ошибка подключения к Bot API: %v", err)
+	}
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, slog.LevelInfo, logging.FormatJSON)
+	if err != nil {
+		t.Fatalf("ошибка создания журнала: %v", err)
+	}
+	client := telegram.WithLogging(api, logger)
+
+	// Поддельный сервер отвечает 400 на сообщение без чата
+	if _, err := client.Send(tgbotapi.NewMessage(0, "привет")); err == nil {
+		t.Fatal("нет ошибки при отправке без чата")
+	}
+
+	var entry struct {
+		Level        string `json:"level"`
+		Msg          string `json:"msg"`
+		Method       string `json:"method"`
+		TelegramCode int    `json:"telegram_code"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("запись журнала %q не разобрана: %v", buf.String(), err)
+	}
+	if entry.Level != "WARN" || entry.Method != "sendMessage" || entry.TelegramCode != 400 {
+		t.Fatalf("запись журнала %+v, ожидалась WARN sendMessage с telegram_code 400", entry)
+	}
+
+	// Успешные запросы в журнал не попадают
+	buf.Reset()
+	if _, err := client.Send(tgbotapi.NewMessage(-100, "привет")); err != nil {
+		t.Fatalf("ошибка отправки: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("лишняя запись журнала: %s", buf.String())
+	}
+}
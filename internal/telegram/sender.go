@@ -0,0 +1,358 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/metrics"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// maxAttempts сколько раз Sender пытается выполнить запрос, включая первую попытку
+	maxAttempts = 5
+	// baseBackoff и maxBackoff пауза перед повтором после ошибки сервера или соединения:
+	// удваивается с каждой попыткой, но не превышает maxBackoff
+	baseBackoff = 500 * time.Millisecond
+	maxBackoff  = 30 * time.Second
+	// maxRetryAfter дольше этого Sender не ждет по ответу 429, а сообщает об ошибке:
+	// обработчик обновления или рассылка не должны зависать надолго
+	maxRetryAfter = time.Minute
+)
+
+// ErrSenderClosed возвращается запросам, которые ждали очереди или повтора, когда Sender остановлен
+var ErrSenderClosed = errors.New("очередь отправки остановлена")
+
+// Rate ограничение частоты: один запрос в Every, но не более Burst запросов подряд
+type Rate struct {
+	Every time.Duration
+	Burst int
+}
+
+// Limits ограничения частоты отправки сообщений
+type Limits struct {
+	Global  Rate // Все чаты вместе
+	Private Rate // Один личный чат
+	Group   Rate // Одна группа
+}
+
+// DefaultLimits ограничения из документации Telegram: не более 30 сообщений в секунду
+// всего, около одного в секунду в личный чат и не более 20 в минуту в группу.
+// Группам запас не дается: с ним в первую минуту ушло бы больше 20 сообщений.
+var DefaultLimits = Limits{
+	Global:  Rate{Every: time.Second / 30, Burst: 30},
+	Private: Rate{Every: time.Second, Burst: 3},
+	Group:   Rate{Every: 3 * time.Second, Burst: 1},
+}
+
+// Sender очередь исходящих запросов к Bot API. Сообщения ждут своей очереди по общему
+// ограничению частоты и ограничению чата, после ответа 429 запрос повторяется через
+// retry_after, после ошибок сервера (5xx) и ошибок соединения, при которых запрос не был
+// отправлен, — с нарастающей паузой. Остальные ошибки окончательные: они записываются
+// в журнал и возвращаются сразу. Обрыв соединения после отправки не повторяется,
+// потому что Telegram мог уже доставить сообщение, и повтор отправил бы его в группу дважды.
+type Sender struct {
+	Client
+	logger *slog.Logger
+
+	clock   clock.Clock
+	global  *limiter
+	private *limiter
+	group   *limiter
+	backoff time.Duration // Пауза перед первым повтором; в тестах меньше baseBackoff
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSender создает очередь отправки через client с ограничениями limits.
+// Очередь и паузы после 429 отсчитываются по часам clk.
+func NewSender(client Client, limits Limits, clk clock.Clock, logger *slog.Logger) *Sender {
+	return &Sender{
+		Client:  client,
+		logger:  logger,
+		clock:   clk,
+		global:  newLimiter(limits.Global),
+		private: newLimiter(limits.Private),
+		group:   newLimiter(limits.Group),
+		backoff: baseBackoff,
+		done:    make(chan struct{}),
+	}
+}
+
+// Close останавливает очередь: ожидающие запросы завершаются с ErrSenderClosed
+func (s *Sender) Close() {
+	s.closeOnce.Do(func() { close(s.done) })
+}
+
+// Send отправляет сообщение в порядке очереди, повторяя его после временных ошибок
+func (s *Sender) Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return s.SendContext(context.Background(), chattable)
+}
+
+// SendContext отправляет сообщение как Send, но перестает ждать очереди и повтора,
+// когда ctx отменен, и возвращает ошибку ctx
+func (s *Sender) SendContext(ctx context.Context, chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	var message tgbotapi.Message
+	err := s.do(ctx, chattable, true, func() error {
+		var err error
+		message, err = s.Client.Send(chattable)
+		return err
+	})
+	return message, err
+}
+
+// Request выполняет запрос, повторяя его после временных ошибок. Запросы, которые
+// не являются сообщениями, например ответы на нажатия кнопок, не ждут очереди.
+func (s *Sender) Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return s.RequestContext(context.Background(), chattable)
+}
+
+// RequestContext выполняет запрос как Request, но перестает ждать повтора, когда ctx отменен
+func (s *Sender) RequestContext(ctx context.Context, chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	var resp *tgbotapi.APIResponse
+	err := s.do(ctx, chattable, false, func() error {
+		var err error
+		resp, err = s.Client.Request(chattable)
+		return err
+	})
+	return resp, err
+}
+
+// do выполняет call не более maxAttempts раз. Если limited, каждая попытка ждет очереди.
+// Ожидание очереди и паузы перед повтором прерываются отменой ctx; начатый запрос
+// доводится до конца, потому что клиент Bot API не принимает контекст.
+func (s *Sender) do(ctx context.Context, chattable tgbotapi.Chattable, limited bool, call func() error) error {
+	method, chatID := describe(chattable)
+
+	for attempt := 1; ; attempt++ {
+		if limited {
+			if err := s.wait(ctx, chatID); err != nil {
+				return err
+			}
+		} else if err := s.sleep(ctx, 0); err != nil {
+			return err
+		}
+
+		err := call()
+		if err == nil {
+			metrics.TelegramRequests.WithLabelValues(method, "ok").Inc()
+			return nil
+		}
+
+		delay, retry := s.retryDelay(err, attempt)
+		if !retry || attempt == maxAttempts {
+			metrics.TelegramRequests.WithLabelValues(method, "failed").Inc()
+			s.logger.Error("Запрос к Bot API не выполнен", append([]any{
+				slog.String("method", method),
+				slog.Int64("chat_id", chatID),
+				slog.Int("attempts", attempt),
+			}, ErrorAttrs(err)...)...)
+			return err
+		}
+		metrics.TelegramRequests.WithLabelValues(method, "retried").Inc()
+
+		// После 429 в этот чат, а для запросов без чата — во все, не пишем до конца паузы
+		if isFlood(err) {
+			until := s.clock.Now().Add(delay)
+			if chatID != 0 {
+				s.chatLimiter(chatID).delay(chatID, until)
+			} else {
+				s.global.delay(0, until)
+			}
+		}
+		if err := s.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// retryDelay возвращает паузу перед повтором после ошибки err на попытке attempt
+// и false, если ошибка окончательная
+func (s *Sender) retryDelay(err error, attempt int) (time.Duration, bool) {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		// Ошибка сети или ответ не в формате Bot API, например от прокси.
+		// Повторять безопасно, только если запрос не дошел до сервера.
+		return s.backoffDelay(attempt), notSent(err)
+	}
+
+	switch {
+	case apiErr.Code == 429:
+		delay := time.Duration(max(apiErr.RetryAfter, 1)) * time.Second
+		return delay, delay <= maxRetryAfter
+	case apiErr.Code >= 500:
+		return s.backoffDelay(attempt), true
+	default:
+		return 0, false
+	}
+}
+
+// backoffDelay возвращает паузу перед повтором attempt: удвоенную предыдущую со случайным
+// разбросом, чтобы повторы из разных чатов не приходили одновременно
+func (s *Sender) backoffDelay(attempt int) time.Duration {
+	d := min(s.backoff<<(attempt-1), maxBackoff)
+	return d/2 + rand.N(d/2+1)
+}
+
+// notSent сообщает, что запрос не был отправлен: не удалось найти адрес сервера или подключиться к нему
+func notSent(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// IsPermanent сообщает, что Telegram окончательно отклонил запрос и повтор вернет ту же ошибку
+func IsPermanent(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code != 429 && apiErr.Code < 500
+}
+
+// IsChatUnavailable сообщает, что бот больше не может писать в чат:
+// его удалили из группы, пользователь заблокировал бота или чат удален
+func IsChatUnavailable(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.Code {
+	case 403:
+		return true
+	case 400:
+		description := strings.ToLower(apiErr.Message)
+		return strings.Contains(description, "chat not found") || strings.Contains(description, "chat was deactivated")
+	default:
+		return false
+	}
+}
+
+// MigratedTo возвращает новый ID чата, если Telegram отклонил запрос, потому что группа
+// стала супергруппой, и 0 для остальных ошибок
+func MigratedTo(err error) int64 {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return 0
+	}
+	return apiErr.MigrateToChatID
+}
+
+// isFlood сообщает, что Telegram отклонил запрос из-за превышения частоты
+func isFlood(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == 429
+}
+
+// wait ждет очереди по общему ограничению и ограничению чата chatID
+func (s *Sender) wait(ctx context.Context, chatID int64) error {
+	now := s.clock.Now()
+	d := s.global.reserve(0, now)
+	if chatID != 0 {
+		d = max(d, s.chatLimiter(chatID).reserve(chatID, now))
+	}
+	if d > 0 {
+		metrics.TelegramWait.Observe(d.Seconds())
+	}
+	return s.sleep(ctx, d)
+}
+
+// chatLimiter возвращает ограничение для чата: у групп отрицательные идентификаторы
+func (s *Sender) chatLimiter(chatID int64) *limiter {
+	if chatID < 0 {
+		return s.group
+	}
+	return s.private
+}
+
+// sleep ждет d, остановки очереди или отмены ctx
+func (s *Sender) sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		select {
+		case <-s.done:
+			return ErrSenderClosed
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			return nil
+		}
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-s.done:
+		return ErrSenderClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// limiter ограничивает частоту запросов по ключам алгоритмом GCRA: для каждого ключа
+// хранится время, к которому освободится вся очередь
+type limiter struct {
+	mu          sync.Mutex
+	rate        Rate
+	next        map[int64]time.Time
+	lastCleanup time.Time
+}
+
+// newLimiter создает ограничение с частотой rate
+func newLimiter(rate Rate) *limiter {
+	return &limiter{rate: rate, next: make(map[int64]time.Time)}
+}
+
+// reserve занимает место в очереди ключа key и возвращает, сколько ждать до отправки
+func (l *limiter) reserve(key int64, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.cleanup(now)
+
+	tat := l.next[key]
+	if tat.Before(now) {
+		tat = now
+	}
+	l.next[key] = tat.Add(l.rate.Every)
+
+	// Первые Burst запросов уходят сразу, следующие — с интервалом Every
+	allowAt := tat.Add(-time.Duration(max(l.rate.Burst-1, 0)) * l.rate.Every)
+	return max(allowAt.Sub(now), 0)
+}
+
+// delay откладывает все запросы ключа key до until
+func (l *limiter) delay(key int64, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	burst := time.Duration(max(l.rate.Burst-1, 0)) * l.rate.Every
+	if until = until.Add(burst); until.After(l.next[key]) {
+		l.next[key] = until
+	}
+}
+
+// cleanup раз в минуту удаляет ключи, очередь которых уже освободилась
+func (l *limiter) cleanup(now time.Time) {
+	if now.Sub(l.lastCleanup) < time.Minute {
+		return
+	}
+	l.lastCleanup = now
+
+	for key, tat := range l.next {
+		if !tat.After(now) {
+			delete(l.next, key)
+		}
+	}
+}
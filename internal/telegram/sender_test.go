@@ -0,0 +1,317 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"Eldarius_bot/internal/clock"
+	"Eldarius_bot/internal/logging"
+	"Eldarius_bot/internal/telegram/telegramtest"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// newTestSender создает очередь отправки без ограничений частоты к поддельному Bot API
+func newTestSender(t *testing.T) (*Sender, *telegramtest.Server) {
+	t.Helper()
+
+	srv := telegramtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(telegramtest.Token, srv.Endpoint())
+	if err != nil {
+		t.Fatalf("ошибка подключения к Bot API: %v", err)
+	}
+
+	unlimited := Rate{Every: time.Millisecond, Burst: 1000}
+	s := NewSender(api, Limits{Global: unlimited, Private: unlimited, Group: unlimited}, clock.Real{}, logging.Discard())
+	s.backoff = time.Millisecond
+	t.Cleanup(s.Close)
+	return s, srv
+}
+
+func TestSenderRetries(t *testing.T) {
+	const chatID = -1001
+
+	tests := []struct {
+		name      string
+		failures  []int // Коды ошибок перед успешным ответом
+		wantCode  int   // Код окончательной ошибки, 0 — сообщение отправлено
+		wantAfter time.Duration
+	}{
+		{name: "429 повторяется через retry_after", failures: []int{429}, wantAfter: time.Second},
+		{name: "ошибки сервера повторяются", failures: []int{500, 502, 503}},
+		{name: "403 окончательная", failures: []int{403}, wantCode: 403},
+		{name: "попытки исчерпаны", failures: []int{500, 500, 500, 500, 500}, wantCode: 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, srv := newTestSender(t)
+			for _, code := range tt.failures {
+				srv.FailNext("sendMessage", code, 1)
+			}
+
+			start := time.Now()
+			_, err := s.Send(tgbotapi.NewMessage(chatID, "С днем рождения!"))
+			elapsed := time.Since(start)
+
+			sent := len(srv.Messages(chatID))
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("ошибка отправки: %v", err)
+				}
+				if sent != 1 {
+					t.Fatalf("отправлено %d сообщений, ожидалось 1", sent)
+				}
+			} else {
+				var apiErr *tgbotapi.Error
+				if !errors.As(err, &apiErr) || apiErr.Code != tt.wantCode {
+					t.Fatalf("ошибка %v, ожидался код %d", err, tt.wantCode)
+				}
+				if sent != 0 {
+					t.Fatalf("отправлено %d сообщений после окончательной ошибки", sent)
+				}
+			}
+			if elapsed < tt.wantAfter {
+				t.Fatalf("повтор через %v, ожидалось не раньше %v", elapsed, tt.wantAfter)
+			}
+		})
+	}
+}
+
+func TestSenderNetworkErrors(t *testing.T) {
+	s := NewSender(nil, DefaultLimits, clock.Real{}, logging.Discard())
+
+	tests := []struct {
+		name      string
+		err       error
+		wantRetry bool
+	}{
+		{
+			name:      "подключение отклонено",
+			err:       &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}},
+			wantRetry: true,
+		},
+		{
+			name:      "адрес не найден",
+			err:       &url.Error{Op: "Post", Err: &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Name: "api.telegram.org"}}},
+			wantRetry: true,
+		},
+		{
+			name: "соединение сброшено после отправки",
+			err:  &url.Error{Op: "Post", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}},
+		},
+		{
+			name: "соединение закрыто без ответа",
+			err:  &url.Error{Op: "Post", Err: io.EOF},
+		},
+		{
+			name: "нет ответа за отведенное время",
+			err:  &url.Error{Op: "Post", Err: context.DeadlineExceeded},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, retry := s.retryDelay(tt.err, 1); retry != tt.wantRetry {
+				t.Fatalf("повтор = %v, ожидалось %v", retry, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestSenderDoesNotResendAfterDisconnect(t *testing.T) {
+	// Сервер получает запрос и закрывает соединение без ответа, как при обрыве после доставки
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == fmt.Sprintf("/bot%s/getMe", telegramtest.Token) {
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"username":"test_bot"}}`)
+			return
+		}
+		requests.Add(1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	api, err := tgbotapi.NewBotAPIWithAPIEndpoint(telegramtest.Token, srv.URL+"/bot%s/%s")
+	if err != nil {
+		t.Fatalf("ошибка подключения к Bot API: %v", err)
+	}
+	s := NewSender(api, DefaultLimits, clock.Real{}, logging.Discard())
+	s.backoff = time.Millisecond
+	t.Cleanup(s.Close)
+
+	if _, err := s.Send(tgbotapi.NewMessage(-1001, "С днем рождения!")); err == nil {
+		t.Fatal("обрыв соединения не вернул ошибку")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("сообщение отправлено %d раз, ожидалась одна попытка", got)
+	}
+}
+
+func TestSenderCloseInterruptsWait(t *testing.T) {
+	s, srv := newTestSender(t)
+	srv.FailNext("sendMessage", 429, 30)
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := s.Send(tgbotapi.NewMessage(42, "привет"))
+		result <- err
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	s.Close()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrSenderClosed) {
+			t.Fatalf("ошибка %v, ожидалась ErrSenderClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close не прервал ожидание retry_after")
+	}
+}
+
+func TestSenderContext(t *testing.T) {
+	const chatID = -1001
+
+	tests := []struct {
+		name  string
+		setup func(s *Sender, srv *telegramtest.Server)
+	}{
+		{
+			name: "пауза после 429",
+			setup: func(s *Sender, srv *telegramtest.Server) {
+				srv.FailNext("sendMessage", 429, 30)
+			},
+		},
+		{
+			name: "очередь группы",
+			setup: func(s *Sender, srv *telegramtest.Server) {
+				s.group = newLimiter(Rate{Every: time.Hour, Burst: 1})
+				if _, err := s.Send(tgbotapi.NewMessage(chatID, "первое")); err != nil {
+					t.Fatalf("ошибка отправки: %v", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, srv := newTestSender(t)
+			tt.setup(s, srv)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			_, err := s.SendContext(ctx, tgbotapi.NewMessage(chatID, "С днем рождения!"))
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("ошибка %v, ожидалась context.DeadlineExceeded", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("отправка прервана через %v", elapsed)
+			}
+
+			// Очередь продолжает работать для других чатов
+			if _, err := s.Send(tgbotapi.NewMessage(42, "привет")); err != nil {
+				t.Fatalf("после отмены очередь не работает: %v", err)
+			}
+		})
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := newLimiter(Rate{Every: time.Second, Burst: 2})
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Первые Burst запросов уходят сразу, следующие встают в очередь с интервалом Every
+	for i, want := range []time.Duration{0, 0, time.Second, 2 * time.Second} {
+		if got := l.reserve(1, now); got != want {
+			t.Fatalf("запрос %d: ожидание %v, ожидалось %v", i+1, got, want)
+		}
+	}
+
+	// У другого ключа своя очередь
+	if got := l.reserve(2, now); got != 0 {
+		t.Fatalf("другой чат ждет %v", got)
+	}
+
+	// Через 3 секунды очередь освободилась и снова доступен один запрос без ожидания
+	if got := l.reserve(1, now.Add(3*time.Second)); got != 0 {
+		t.Fatalf("после паузы ожидание %v", got)
+	}
+
+	// После 429 все запросы ключа ждут окончания паузы
+	l.delay(2, now.Add(10*time.Second))
+	if got := l.reserve(2, now); got != 10*time.Second {
+		t.Fatalf("после 429 ожидание %v, ожидалось 10s", got)
+	}
+}
+
+func TestDefaultGroupLimit(t *testing.T) {
+	l := newLimiter(DefaultLimits.Group)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Telegram принимает не более 20 сообщений в минуту в одну группу
+	sent := 0
+	for range 30 {
+		if l.reserve(1, now) < time.Minute {
+			sent++
+		}
+	}
+	if sent != 20 {
+		t.Fatalf("за первую минуту уходит %d сообщений в группу, ожидалось 20", sent)
+	}
+}
+
+func TestPermanentErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		wantPermanent   bool
+		wantUnavailable bool
+		wantMigratedTo  int64
+	}{
+		{name: "бота удалили", err: &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was kicked from the group chat"}, wantPermanent: true, wantUnavailable: true},
+		{name: "чат не найден", err: &tgbotapi.Error{Code: 400, Message: "Bad Request: chat not found"}, wantPermanent: true, wantUnavailable: true},
+		{name: "неверный запрос", err: &tgbotapi.Error{Code: 400, Message: "Bad Request: message is too long"}, wantPermanent: true},
+		{name: "429", err: &tgbotapi.Error{Code: 429, ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 120}}},
+		{name: "ошибка сервера", err: &tgbotapi.Error{Code: 502, Message: "Bad Gateway"}},
+		{name: "ошибка сети", err: &url.Error{Op: "Post", Err: io.EOF}},
+		{
+			name: "группа стала супергруппой",
+			err: &tgbotapi.Error{
+				Code:               400,
+				Message:            "Bad Request: group chat was upgraded to a supergroup chat",
+				ResponseParameters: tgbotapi.ResponseParameters{MigrateToChatID: -1002001},
+			},
+			wantPermanent:  true,
+			wantMigratedTo: -1002001,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsPermanent(tt.err); got != tt.wantPermanent {
+				t.Errorf("IsPermanent = %v, ожидалось %v", got, tt.wantPermanent)
+			}
+			if got := IsChatUnavailable(tt.err); got != tt.wantUnavailable {
+				t.Errorf("IsChatUnavailable = %v, ожидалось %v", got, tt.wantUnavailable)
+			}
+			if got := MigratedTo(tt.err); got != tt.wantMigratedTo {
+				t.Errorf("MigratedTo = %d, ожидалось %d", got, tt.wantMigratedTo)
+			}
+		})
+	}
+}
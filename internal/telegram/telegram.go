@@ -0,0 +1,53 @@
+// Package telegram описывает обращения бота к Telegram Bot API.
+// Обработчик, планировщик и сервис зависят от этих интерфейсов, а не от *tgbotapi.BotAPI,
+// поэтому их можно проверить без настоящего Telegram (см. пакет telegramtest).
+//
+// Sender отправляет сообщения с учетом ограничений частоты Telegram и повторяет запросы
+// после временных ошибок, WithLogging записывает ошибки Bot API в журнал.
+package telegram
+
+import (
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Messenger отправляет сообщения и запросы к Bot API
+type Messenger interface {
+	// Send отправляет сообщение и возвращает его в том виде, в каком его сохранил Telegram
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	// Request выполняет запрос, результат которого не является сообщением
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	// GetChatAdministrators возвращает администраторов чата
+	GetChatAdministrators(config tgbotapi.ChatAdministratorsConfig) ([]tgbotapi.ChatMember, error)
+}
+
+// ContextMessenger Messenger, который перестает ждать очереди отправки и повтора запроса,
+// когда контекст отменен. Его реализует Sender.
+type ContextMessenger interface {
+	Messenger
+	// SendContext отправляет сообщение, пока не отменен ctx
+	SendContext(ctx context.Context, c tgbotapi.Chattable) (tgbotapi.Message, error)
+	// RequestContext выполняет запрос, пока не отменен ctx
+	RequestContext(ctx context.Context, c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+}
+
+// Client Messenger, который также получает обновления от Telegram
+type Client interface {
+	Messenger
+	// GetMe возвращает данные бота; успешный ответ означает, что Bot API доступен и токен верен
+	GetMe() (tgbotapi.User, error)
+	// GetUpdatesChan запускает получение обновлений методом getUpdates
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	// StopReceivingUpdates останавливает получение обновлений и закрывает канал
+	StopReceivingUpdates()
+	// MakeRequest вызывает метод Bot API с произвольными параметрами,
+	// например те, которых нет в конфигурациях библиотеки
+	MakeRequest(endpoint string, params tgbotapi.Params) (*tgbotapi.APIResponse, error)
+}
+
+// Клиент библиотеки должен удовлетворять интерфейсам
+var _ Client = (*tgbotapi.BotAPI)(nil)
+
+// Очередь отправки должна прерываться по контексту
+var _ ContextMessenger = (*Sender)(nil)
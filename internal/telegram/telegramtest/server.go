@@ -0,0 +1,682 @@
+// Package telegramtest содержит поддельный Telegram Bot API для тестов без доступа к Telegram.
+//
+// Server запускается в процессе теста на httptest.Server и понимает методы, которыми пользуется бот:
+// getMe, getUpdates, setWebhook, deleteWebhook, sendMessage, sendSticker, sendAnimation,
+// editMessageText, answerCallbackQuery и getChatAdministrators. Обычный клиент tgbotapi
+// подключается к нему через tgbotapi.NewBotAPIWithAPIEndpoint(Token, server.Endpoint()).
+//
+// Пока установлен webhook, обновления по очереди отправляются на его адрес,
+// как это делает Telegram, а getUpdates отклоняется.
+package telegramtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Учетные данные поддельного бота
+const (
+	Token       = "123456:TEST-TOKEN"
+	BotID       = 123456
+	BotUsername = "test_birthday_bot"
+)
+
+const (
+	// waitTimeout сколько ждать ответа бота в методах Wait*
+	waitTimeout = 5 * time.Second
+	// maxPollTimeout дольше этого запрос getUpdates не ждет новых обновлений,
+	// чтобы остановка клиента не затягивала тесты на весь timeout клиента
+	maxPollTimeout = time.Second
+)
+
+// CallbackAnswer ответ бота на нажатие кнопки
+type CallbackAnswer struct {
+	CallbackID string
+	Text       string
+	ShowAlert  bool
+}
+
+// Server поддельный Telegram Bot API
+type Server struct {
+	srv  *httptest.Server
+	done chan struct{}
+
+	mu            sync.Mutex
+	changed       chan struct{} // Закрывается и заменяется при каждом изменении состояния
+	updates       []tgbotapi.Update
+	nextUpdateID  int
+	nextMessageID int
+	nextCallback  int
+	messages      []*tgbotapi.Message // Сообщения бота в порядке отправки, с учетом изменений
+	answers       []CallbackAnswer
+	admins        map[int64][]int64
+	chats         map[int64]*tgbotapi.Chat // Чаты, из которых писали боту
+	webhook       Webhook
+	webhookGen    int                  // Меняется при каждой установке и удалении webhook
+	failures      map[string][]failure // Ошибки, которые вернут следующие вызовы метода
+}
+
+// Webhook параметры установленного webhook
+type Webhook struct {
+	URL    string
+	Secret string
+}
+
+// NewServer запускает поддельный Bot API
+func NewServer() *Server {
+	s := &Server{
+		done:     make(chan struct{}),
+		changed:  make(chan struct{}),
+		admins:   make(map[int64][]int64),
+		chats:    make(map[int64]*tgbotapi.Chat),
+		failures: make(map[string][]failure),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close останавливает сервер, прерывая ожидающие запросы getUpdates
+func (s *Server) Close() {
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	s.srv.Close()
+}
+
+// Endpoint возвращает шаблон адреса API для tgbotapi.NewBotAPIWithAPIEndpoint
+func (s *Server) Endpoint() string {
+	return s.srv.URL + "/bot%s/%s"
+}
+
+// Bot возвращает данные поддельного бота
+func Bot() *tgbotapi.User {
+	return &tgbotapi.User{ID: BotID, IsBot: true, FirstName: "Birthday Bot", UserName: BotUsername}
+}
+
+// SetAdmins задает администраторов чата для getChatAdministrators
+func (s *Server) SetAdmins(chatID int64, userIDs ...int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.admins[chatID] = userIDs
+}
+
+// Webhook возвращает параметры установленного webhook; пустой URL — webhook не установлен
+func (s *Server) Webhook() Webhook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.webhook
+}
+
+// PushUpdate добавляет обновление в очередь getUpdates или webhook и возвращает его ID
+func (s *Server) PushUpdate(update tgbotapi.Update) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextUpdateID++
+	update.UpdateID = s.nextUpdateID
+	s.updates = append(s.updates, update)
+	s.notify()
+	return update.UpdateID
+}
+
+// SendText отправляет боту текстовое сообщение от пользователя.
+// Команды и упоминания бота размечаются так же, как это делает Telegram.
+func (s *Server) SendText(chat *tgbotapi.Chat, from *tgbotapi.User, text string) *tgbotapi.Message {
+	s.mu.Lock()
+	s.chats[chat.ID] = chat
+	s.nextMessageID++
+	message := &tgbotapi.Message{
+		MessageID: s.nextMessageID,
+		From:      from,
+		Chat:      chat,
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+		Entities:  entities(text),
+	}
+	s.mu.Unlock()
+
+	s.PushUpdate(tgbotapi.Update{Message: message})
+	return message
+}
+
+// PressButton нажимает кнопку с данными data под сообщением бота и возвращает ID нажатия
+func (s *Server) PressButton(from *tgbotapi.User, message *tgbotapi.Message, data string) string {
+	s.mu.Lock()
+	s.nextCallback++
+	id := strconv.Itoa(s.nextCallback)
+	s.mu.Unlock()
+
+	s.PushUpdate(tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      id,
+		From:    from,
+		Message: message,
+		Data:    data,
+	}})
+	return id
+}
+
+// Messages возвращает копии сообщений бота в чате в порядке отправки
+func (s *Server) Messages(chatID int64) []tgbotapi.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []tgbotapi.Message
+	for _, m := range s.messages {
+		if m.Chat.ID == chatID {
+			result = append(result, *m)
+		}
+	}
+	return result
+}
+
+// CallbackAnswers возвращает ответы бота на нажатия кнопок
+func (s *Server) CallbackAnswers() []CallbackAnswer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CallbackAnswer(nil), s.answers...)
+}
+
+// WaitMessages ждет, пока бот отправит в чат не меньше n сообщений, и возвращает их
+func (s *Server) WaitMessages(t testing.TB, chatID int64, n int) []tgbotapi.Message {
+	t.Helper()
+
+	var messages []tgbotapi.Message
+	if !s.wait(func() bool {
+		messages = s.Messages(chatID)
+		return len(messages) >= n
+	}) {
+		t.Fatalf("telegramtest: в чате %d %d сообщений бота, ожидалось %d", chatID, len(messages), n)
+	}
+	return messages
+}
+
+// WaitCallbackAnswer ждет ответа бота на нажатие кнопки с ID id
+func (s *Server) WaitCallbackAnswer(t testing.TB, id string) CallbackAnswer {
+	t.Helper()
+
+	var answer CallbackAnswer
+	if !s.wait(func() bool {
+		for _, a := range s.CallbackAnswers() {
+			if a.CallbackID == id {
+				answer = a
+				return true
+			}
+		}
+		return false
+	}) {
+		t.Fatalf("telegramtest: бот не ответил на нажатие кнопки %s", id)
+	}
+	return answer
+}
+
+// wait ждет выполнения условия, проверяя его при каждом изменении состояния сервера.
+// Возвращает false, если условие не выполнилось за waitTimeout.
+func (s *Server) wait(cond func() bool) bool {
+	deadline := time.After(waitTimeout)
+	for {
+		s.mu.Lock()
+		changed := s.changed
+		s.mu.Unlock()
+
+		if cond() {
+			return true
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+// notify будит ожидающих изменения состояния. Вызывается под s.mu.
+func (s *Server) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// entities размечает команду в начале текста и упоминания бота
+func entities(text string) []tgbotapi.MessageEntity {
+	var result []tgbotapi.MessageEntity
+	if strings.HasPrefix(text, "/") {
+		command, _, _ := strings.Cut(text, " ")
+		result = append(result, tgbotapi.MessageEntity{Type: "bot_command", Offset: 0, Length: utf16Len(command)})
+	}
+
+	mention := "@" + BotUsername
+	if i := strings.Index(text, mention); i >= 0 && !strings.HasPrefix(text, "/") {
+		result = append(result, tgbotapi.MessageEntity{Type: "mention", Offset: utf16Len(text[:i]), Length: utf16Len(mention)})
+	}
+	return result
+}
+
+// utf16Len возвращает длину строки в единицах UTF-16, в которых Telegram считает смещения
+func utf16Len(text string) int {
+	n := 0
+	for _, r := range text {
+		if r >= 0x10000 {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// FailNext заставляет следующий вызов метода method вернуть ошибку с кодом code, не выполняя его.
+// Для кода 429 retryAfter задает, через сколько секунд можно повторить запрос.
+// Несколько вызовов FailNext ставят ошибки в очередь.
+func (s *Server) FailNext(method string, code int, retryAfter int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], failure{code: code, retryAfter: retryAfter})
+}
+
+// FailMigrated заставляет следующий вызов метода method вернуть ошибку 400, как для группы,
+// которая стала супергруппой с ID newChatID
+func (s *Server) FailMigrated(method string, newChatID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], failure{
+		code:        http.StatusBadRequest,
+		description: "Bad Request: group chat was upgraded to a supergroup chat",
+		migrateTo:   newChatID,
+	})
+}
+
+// failure ошибка, заданная FailNext или FailMigrated
+type failure struct {
+	code        int
+	retryAfter  int
+	description string // Пустое — стандартный текст кода
+	migrateTo   int64
+}
+
+// nextFailure забирает ошибку, заданную FailNext для метода method
+func (s *Server) nextFailure(method string) (failure, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queue := s.failures[method]
+	if len(queue) == 0 {
+		return failure{}, false
+	}
+	s.failures[method] = queue[1:]
+	return queue[0], true
+}
+
+// writeFailure записывает ответ с ошибкой, заданной FailNext или FailMigrated
+func writeFailure(w http.ResponseWriter, f failure) {
+	description := f.description
+	if description == "" {
+		description = http.StatusText(f.code)
+	}
+	resp := map[string]any{
+		"ok":          false,
+		"error_code":  f.code,
+		"description": description,
+	}
+	switch {
+	case f.retryAfter > 0:
+		resp["parameters"] = map[string]any{"retry_after": f.retryAfter}
+	case f.migrateTo != 0:
+		resp["parameters"] = map[string]any{"migrate_to_chat_id": f.migrateTo}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(f.code)
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// apiError ошибка в формате ответа Bot API
+type apiError struct {
+	code        int
+	description string
+}
+
+// serveHTTP разбирает запрос вида /bot<токен>/<метод> и вызывает обработчик метода
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	token, method, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/bot"), "/")
+	if !ok || token != Token {
+		writeResponse(w, nil, &apiError{http.StatusUnauthorized, "Unauthorized"})
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeResponse(w, nil, &apiError{http.StatusBadRequest, "Bad Request: " + err.Error()})
+		return
+	}
+
+	if f, ok := s.nextFailure(method); ok {
+		writeFailure(w, f)
+		return
+	}
+
+	var (
+		result any
+		err    *apiError
+	)
+	switch method {
+	case "getMe":
+		result = Bot()
+	case "getUpdates":
+		result, err = s.getUpdates(r)
+	case "setWebhook":
+		result, err = s.setWebhook(r)
+	case "deleteWebhook":
+		result = s.deleteWebhook()
+	case "sendMessage":
+		result, err = s.sendMessage(r, func(m *tgbotapi.Message) { m.Text = r.Form.Get("text") })
+	case "sendSticker":
+		result, err = s.sendMessage(r, func(m *tgbotapi.Message) {
+			m.Sticker = &tgbotapi.Sticker{FileID: r.Form.Get("sticker")}
+		})
+	case "sendAnimation":
+		result, err = s.sendMessage(r, func(m *tgbotapi.Message) {
+			m.Animation = &tgbotapi.Animation{FileID: r.Form.Get("animation")}
+		})
+	case "editMessageText":
+		result, err = s.editMessageText(r)
+	case "answerCallbackQuery":
+		result = s.answerCallbackQuery(r)
+	case "getChatAdministrators":
+		result, err = s.getChatAdministrators(r)
+	default:
+		err = &apiError{http.StatusNotFound, "Not Found"}
+	}
+
+	writeResponse(w, result, err)
+}
+
+// writeResponse записывает ответ в формате Bot API
+func writeResponse(w http.ResponseWriter, result any, err *apiError) {
+	resp := map[string]any{"ok": err == nil}
+	if err != nil {
+		resp["error_code"] = err.code
+		resp["description"] = err.description
+		w.WriteHeader(err.code)
+	} else {
+		resp["result"] = result
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// getUpdates возвращает обновления начиная с offset, ожидая новых до timeout секунд, но не дольше maxPollTimeout
+func (s *Server) getUpdates(r *http.Request) ([]tgbotapi.Update, *apiError) {
+	s.mu.Lock()
+	webhook := s.webhook.URL
+	s.mu.Unlock()
+	if webhook != "" {
+		return nil, &apiError{http.StatusConflict, "Conflict: can't use getUpdates method while webhook is active; use deleteWebhook to delete the webhook first"}
+	}
+
+	offset, _ := strconv.Atoi(r.Form.Get("offset"))
+	timeout, _ := strconv.Atoi(r.Form.Get("timeout"))
+	deadline := time.After(min(time.Duration(timeout)*time.Second, maxPollTimeout))
+
+	for {
+		s.mu.Lock()
+		// Обновления до offset подтверждены клиентом и удаляются из очереди
+		var result []tgbotapi.Update
+		for _, u := range s.updates {
+			if u.UpdateID >= offset {
+				result = append(result, u)
+			}
+		}
+		s.updates = append(s.updates[:0:0], result...)
+		changed := s.changed
+		s.mu.Unlock()
+
+		if len(result) > 0 || timeout <= 0 {
+			return result, nil
+		}
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return []tgbotapi.Update{}, nil
+		case <-s.done:
+			return []tgbotapi.Update{}, nil
+		case <-r.Context().Done():
+			return []tgbotapi.Update{}, nil
+		}
+	}
+}
+
+// setWebhook устанавливает webhook и запускает доставку обновлений на его адрес
+func (s *Server) setWebhook(r *http.Request) (bool, *apiError) {
+	webhook := Webhook{URL: r.Form.Get("url"), Secret: r.Form.Get("secret_token")}
+	if !strings.HasPrefix(webhook.URL, "http://") && !strings.HasPrefix(webhook.URL, "https://") {
+		return false, &apiError{http.StatusBadRequest, "Bad Request: bad webhook: An HTTPS URL must be provided for webhook"}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhook = webhook
+	s.webhookGen++
+	s.notify()
+	go s.deliver(webhook, s.webhookGen)
+	return true, nil
+}
+
+// deleteWebhook удаляет webhook; недоставленные обновления снова доступны через getUpdates
+func (s *Server) deleteWebhook() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.webhook = Webhook{}
+	s.webhookGen++
+	s.notify()
+	return true
+}
+
+// deliver по одному отправляет обновления на webhook, пока он не сменится.
+// Доставленное обновление удаляется из очереди, недоставленное отправляется повторно.
+func (s *Server) deliver(webhook Webhook, gen int) {
+	client := &http.Client{Timeout: waitTimeout}
+	for {
+		s.mu.Lock()
+		if s.webhookGen != gen {
+			s.mu.Unlock()
+			return
+		}
+		var (
+			update  tgbotapi.Update
+			pending = len(s.updates) > 0
+		)
+		if pending {
+			update = s.updates[0]
+		}
+		changed := s.changed
+		s.mu.Unlock()
+
+		if !pending {
+			select {
+			case <-changed:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+
+		if postUpdate(client, webhook, update) {
+			s.mu.Lock()
+			if len(s.updates) > 0 && s.updates[0].UpdateID == update.UpdateID {
+				s.updates = s.updates[1:]
+			}
+			s.mu.Unlock()
+			continue
+		}
+
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// postUpdate отправляет обновление на webhook и сообщает, принято ли оно
+func postUpdate(client *http.Client, webhook Webhook, update tgbotapi.Update) bool {
+	body, err := json.Marshal(update)
+	if err != nil {
+		return false
+	}
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if webhook.Secret != "" {
+		req.Header.Set("X-Telegram-Bot-Api-Secret-Token", webhook.Secret)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// chatID разбирает параметр chat_id
+func chatID(r *http.Request) (int64, *apiError) {
+	id, err := strconv.ParseInt(r.Form.Get("chat_id"), 10, 64)
+	if err != nil || id == 0 {
+		return 0, &apiError{http.StatusBadRequest, "Bad Request: chat not found"}
+	}
+	return id, nil
+}
+
+// inlineKeyboard разбирает параметр reply_markup. ForceReply и пустая разметка дают nil.
+func inlineKeyboard(r *http.Request) *tgbotapi.InlineKeyboardMarkup {
+	raw := r.Form.Get("reply_markup")
+	if raw == "" {
+		return nil
+	}
+
+	var markup tgbotapi.InlineKeyboardMarkup
+	if err := json.Unmarshal([]byte(raw), &markup); err != nil || len(markup.InlineKeyboard) == 0 {
+		return nil
+	}
+	return &markup
+}
+
+// sendMessage сохраняет новое сообщение бота; fill заполняет его содержимое
+func (s *Server) sendMessage(r *http.Request, fill func(m *tgbotapi.Message)) (*tgbotapi.Message, *apiError) {
+	id, err := chatID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Telegram возвращает полные данные чата, если они известны
+	chat, ok := s.chats[id]
+	if !ok {
+		chat = &tgbotapi.Chat{ID: id}
+	}
+
+	s.nextMessageID++
+	message := &tgbotapi.Message{
+		MessageID:   s.nextMessageID,
+		From:        Bot(),
+		Chat:        chat,
+		Date:        int(time.Now().Unix()),
+		ReplyMarkup: inlineKeyboard(r),
+	}
+	fill(message)
+	if message.Text == "" && message.Sticker == nil && message.Animation == nil {
+		return nil, &apiError{http.StatusBadRequest, "Bad Request: message text is empty"}
+	}
+
+	s.messages = append(s.messages, message)
+	s.notify()
+
+	copied := *message
+	return &copied, nil
+}
+
+// editMessageText заменяет текст и клавиатуру сообщения бота
+func (s *Server) editMessageText(r *http.Request) (*tgbotapi.Message, *apiError) {
+	id, err := chatID(r)
+	if err != nil {
+		return nil, err
+	}
+	messageID, _ := strconv.Atoi(r.Form.Get("message_id"))
+	text := r.Form.Get("text")
+	markup := inlineKeyboard(r)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range s.messages {
+		if m.Chat.ID != id || m.MessageID != messageID {
+			continue
+		}
+
+		// Telegram отклоняет изменение, которое ничего не меняет
+		oldMarkup, _ := json.Marshal(m.ReplyMarkup)
+		newMarkup, _ := json.Marshal(markup)
+		if m.Text == text && string(oldMarkup) == string(newMarkup) {
+			return nil, &apiError{http.StatusBadRequest, "Bad Request: message is not modified"}
+		}
+
+		m.Text, m.ReplyMarkup = text, markup
+		m.EditDate = int(time.Now().Unix())
+		s.notify()
+
+		copied := *m
+		return &copied, nil
+	}
+
+	return nil, &apiError{http.StatusBadRequest, "Bad Request: message to edit not found"}
+}
+
+// answerCallbackQuery сохраняет ответ на нажатие кнопки
+func (s *Server) answerCallbackQuery(r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.answers = append(s.answers, CallbackAnswer{
+		CallbackID: r.Form.Get("callback_query_id"),
+		Text:       r.Form.Get("text"),
+		ShowAlert:  r.Form.Get("show_alert") == "true",
+	})
+	s.notify()
+	return true
+}
+
+// getChatAdministrators возвращает администраторов, заданных через SetAdmins
+func (s *Server) getChatAdministrators(r *http.Request) ([]tgbotapi.ChatMember, *apiError) {
+	id, err := chatID(r)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := []tgbotapi.ChatMember{}
+	for _, userID := range s.admins[id] {
+		members = append(members, tgbotapi.ChatMember{
+			User:   &tgbotapi.User{ID: userID},
+			Status: "administrator",
+		})
+	}
+	return members, nil
+}
@@ -0,0 +1,295 @@
+// Package templates формирует тексты уведомлений о днях рождения по шаблонам text/template.
+// У каждого вида уведомления есть шаблон по умолчанию на каждом языке из каталога i18n;
+// группа может заменить его своим.
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"Eldarius_bot/internal/i18n"
+)
+
+// Kind вид уведомления, для которого задается шаблон
+type Kind string
+
+// Виды уведомлений
+const (
+	KindBirthday Kind = "birthday" // Поздравление в день рождения
+	KindJubilee  Kind = "jubilee"  // Поздравление с юбилеем
+	KindReminder Kind = "reminder" // Напоминание о приближающемся дне рождения
+)
+
+// maxTemplateLength максимальная длина шаблона и готового текста (лимит сообщения Telegram)
+const maxTemplateLength = 4096
+
+// Ошибки проверки шаблона. Подробности ошибок разбора и заполнения дописываются к ним
+// для журнала, а пользователю показывается текст из каталога по самой ошибке.
+var (
+	ErrSyntax  = errors.New("ошибка в шаблоне")
+	ErrExecute = errors.New("ошибка заполнения шаблона")
+	ErrEmpty   = errors.New("шаблон дает пустой текст")
+	ErrTooLong = errors.New("шаблон или текст по нему длиннее лимита сообщения")
+	// ErrRange шаблон содержит цикл range. В данных шаблона нет списков, а {{range N}}
+	// с большим N занял бы процессор надолго: ограничение длины текста цикл без вывода не остановит.
+	ErrRange = errors.New("циклы range в шаблонах не поддерживаются")
+)
+
+// Kinds возвращает все виды уведомлений в порядке показа пользователю
+func Kinds() []Kind {
+	return []Kind{KindBirthday, KindJubilee, KindReminder}
+}
+
+// ParseKind возвращает вид уведомления по названию
+func ParseKind(name string) (Kind, bool) {
+	for _, k := range Kinds() {
+		if string(k) == strings.ToLower(name) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// Data данные, доступные в шаблоне
+type Data struct {
+	Name      string // Имя именинника
+	Age       int    // Сколько лет исполняется, 0 — год рождения неизвестен
+	DaysUntil int    // Сколько дней осталось до дня рождения, 0 — сегодня
+	When      string // Срок словами: "Завтра", "Через неделю", "Через 5 дней"
+	Date      string // Дата дня рождения: "2 января"
+	Jubilee   bool   // День рождения — юбилей
+	Greeting  string // Поздравление из библиотеки группы, может быть пустым
+}
+
+// Default возвращает шаблон по умолчанию для вида уведомления на языке l
+func Default(l i18n.Localizer, kind Kind) string {
+	return l.T("template." + string(kind))
+}
+
+// funcs функции, доступные в шаблонах
+func funcs(l i18n.Localizer) template.FuncMap {
+	return template.FuncMap{
+		"years": l.Years, // {{years .Age}} — "год", "года" или "лет"
+		"days":  l.Days,  // {{days .DaysUntil}} — "день", "дня" или "дней"
+	}
+}
+
+// Sample возвращает пример данных для предпросмотра и проверки шаблона
+func Sample(l i18n.Localizer, kind Kind) Data {
+	data := Data{
+		Name:     l.T("template.sample_name"),
+		When:     l.T("when.today"),
+		Date:     l.DayMonth(time.Date(2000, time.January, 2, 0, 0, 0, 0, time.UTC)),
+		Greeting: l.T("template.sample_greeting"),
+	}
+
+	switch kind {
+	case KindJubilee:
+		data.Age, data.Jubilee = 30, true
+	case KindReminder:
+		data.Age, data.Jubilee = 25, true
+		data.DaysUntil, data.When, data.Greeting = 7, l.T("when.week"), ""
+	default:
+		data.Age = 27
+	}
+
+	return data
+}
+
+// parseTemplate разбирает шаблон с функциями языка l и отклоняет шаблоны с циклами
+func parseTemplate(l i18n.Localizer, body string) (*template.Template, error) {
+	tmpl, err := template.New("message").Funcs(funcs(l)).Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSyntax, err)
+	}
+
+	// Цикл может быть и в шаблоне, объявленном через define
+	if anyTree(tmpl, isRange) {
+		return nil, ErrRange
+	}
+	return tmpl, nil
+}
+
+// Render формирует текст по шаблону. Склонения в шаблоне подбираются по правилам языка l.
+func Render(l i18n.Localizer, body string, data Data) (string, error) {
+	tmpl, err := parseTemplate(l, body)
+	if err != nil {
+		return "", err
+	}
+
+	// Ограничиваем вывод, чтобы шаблон с циклом не разрастался без предела
+	buf := &limitedBuffer{limit: maxTemplateLength * 4}
+	if err := tmpl.Execute(buf, data); err != nil {
+		if errors.Is(err, ErrTooLong) {
+			return "", err
+		}
+		return "", fmt.Errorf("%w: %v", ErrExecute, err)
+	}
+
+	text := strings.TrimSpace(buf.String())
+	if text == "" {
+		return "", ErrEmpty
+	}
+	if len(text) > maxTemplateLength {
+		return "", fmt.Errorf("%w: текст по шаблону длиннее %d байт", ErrTooLong, maxTemplateLength)
+	}
+
+	return text, nil
+}
+
+// limitedBuffer буфер, который возвращает ошибку при превышении limit байт
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+// Write дописывает данные в буфер, пока не превышен лимит
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, fmt.Errorf("%w: текст по шаблону длиннее %d байт", ErrTooLong, b.limit)
+	}
+	return b.Buffer.Write(p)
+}
+
+// Validate проверяет шаблон перед сохранением: он должен разбираться
+// и давать непустой текст на примере данных с известным и неизвестным возрастом
+func Validate(l i18n.Localizer, kind Kind, body string) error {
+	if len(body) > maxTemplateLength {
+		return fmt.Errorf("%w: шаблон длиннее %d байт", ErrTooLong, maxTemplateLength)
+	}
+
+	sample := Sample(l, kind)
+	if _, err := Render(l, body, sample); err != nil {
+		return err
+	}
+
+	if kind != KindJubilee {
+		sample.Age, sample.Jubilee = 0, false
+		if _, err := Render(l, body, sample); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RenderOrDefault формирует текст по шаблону группы, а если он пуст или не работает —
+// по шаблону по умолчанию. Ошибка шаблона группы возвращается вместе с текстом,
+// чтобы ее можно было записать в журнал, не прерывая отправку уведомления.
+func RenderOrDefault(l i18n.Localizer, kind Kind, body string, data Data) (string, error) {
+	if body != "" {
+		text, err := Render(l, body, data)
+		if err == nil {
+			return text, nil
+		}
+		fallback, defErr := Render(l, Default(l, kind), data)
+		if defErr != nil {
+			return "", defErr
+		}
+		return fallback, fmt.Errorf("шаблон %s группы не применен: %w", kind, err)
+	}
+
+	return Render(l, Default(l, kind), data)
+}
+
+// UsesGreeting сообщает, выводит ли шаблон группы body поздравление из библиотеки (поле Greeting).
+// Пустой или неразбираемый шаблон заменяется шаблоном по умолчанию, как в RenderOrDefault,
+// поэтому тогда проверяется шаблон по умолчанию.
+func UsesGreeting(l i18n.Localizer, kind Kind, body string) bool {
+	tmpl, err := parseTemplate(l, body)
+	if body == "" || err != nil {
+		if tmpl, err = parseTemplate(l, Default(l, kind)); err != nil {
+			return false
+		}
+	}
+
+	return anyTree(tmpl, func(node parse.Node) bool { return isField(node, "Greeting") })
+}
+
+// anyTree сообщает, есть ли узел, для которого match возвращает true, в основном шаблоне
+// или в шаблонах, объявленных в нем через define
+func anyTree(tmpl *template.Template, match func(parse.Node) bool) bool {
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && anyNode(t.Tree.Root, match) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRange сообщает, что узел — цикл range
+func isRange(node parse.Node) bool {
+	_, ok := node.(*parse.RangeNode)
+	return ok
+}
+
+// isField сообщает, что узел обращается к полю данных name: .Greeting или $.Greeting
+func isField(node parse.Node, name string) bool {
+	switch n := node.(type) {
+	case *parse.FieldNode:
+		return len(n.Ident) > 0 && n.Ident[0] == name
+	case *parse.VariableNode:
+		return len(n.Ident) > 1 && n.Ident[0] == "$" && n.Ident[1] == name
+	}
+	return false
+}
+
+// anyNode обходит узел шаблона и вложенные в него узлы и сообщает, подошел ли какой-нибудь из них под match
+func anyNode(node parse.Node, match func(parse.Node) bool) bool {
+	if node == nil {
+		return false
+	}
+	if match(node) {
+		return true
+	}
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, child := range n.Nodes {
+			if anyNode(child, match) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return anyNode(n.Pipe, match)
+	case *parse.IfNode:
+		return anyBranch(&n.BranchNode, match)
+	case *parse.RangeNode:
+		return anyBranch(&n.BranchNode, match)
+	case *parse.WithNode:
+		return anyBranch(&n.BranchNode, match)
+	case *parse.TemplateNode:
+		return anyNode(n.Pipe, match)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, cmd := range n.Cmds {
+			if anyNode(cmd, match) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			if anyNode(arg, match) {
+				return true
+			}
+		}
+	case *parse.ChainNode:
+		return anyNode(n.Node, match)
+	}
+	return false
+}
+
+// anyBranch обходит условие и обе ветви if, range или with
+func anyBranch(n *parse.BranchNode, match func(parse.Node) bool) bool {
+	return anyNode(n.Pipe, match) || anyNode(n.List, match) || anyNode(n.ElseList, match)
+}
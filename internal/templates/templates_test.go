@@ -0,0 +1,77 @@
+package templates
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"Eldarius_bot/internal/i18n"
+)
+
+func TestValidate(t *testing.T) {
+	ru := i18n.New(i18n.Russian)
+
+	tests := []struct {
+		name    string
+		kind    Kind
+		body    string
+		wantErr error // nil — шаблон принимается
+	}{
+		{name: "шаблон по умолчанию", kind: KindBirthday, body: Default(ru, KindBirthday)},
+		{name: "склонения", kind: KindReminder, body: "{{.Name}}: {{.DaysUntil}} {{days .DaysUntil}}"},
+		{name: "ошибка разбора", kind: KindBirthday, body: "{{.Name", wantErr: ErrSyntax},
+		{name: "неизвестное поле", kind: KindBirthday, body: "{{.Phone}}", wantErr: ErrExecute},
+		{name: "цикл по числу", kind: KindBirthday, body: "{{range 9000000000000000000}}{{end}}x", wantErr: ErrRange},
+		{name: "цикл в ветке if", kind: KindBirthday, body: "{{if .Age}}{{range 10}}{{.}}{{end}}{{end}}{{.Name}}", wantErr: ErrRange},
+		{name: "цикл в define", kind: KindBirthday, body: `{{define "loop"}}{{range 10}}x{{end}}{{end}}{{.Name}}`, wantErr: ErrRange},
+		{name: "шаблон длиннее лимита", kind: KindBirthday, body: strings.Repeat("x", maxTemplateLength+1), wantErr: ErrTooLong},
+		{name: "текст длиннее лимита", kind: KindBirthday, body: strings.Repeat("{{.Greeting}}", 20), wantErr: ErrTooLong},
+		{name: "текст больше буфера", kind: KindBirthday, body: strings.Repeat("{{.Greeting}}", 100), wantErr: ErrTooLong},
+		{name: "пустой текст", kind: KindBirthday, body: "{{if .Jubilee}}С юбилеем!{{end}}", wantErr: ErrEmpty},
+		{name: "пустой текст без возраста", kind: KindBirthday, body: "{{if .Age}}{{.Age}}{{end}}", wantErr: ErrEmpty},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			err := Validate(ru, tt.kind, tt.body)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("проверка шаблона заняла %v", elapsed)
+			}
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("шаблон отклонен: %v", err)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ошибка %v, ожидалась %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUsesGreeting(t *testing.T) {
+	ru := i18n.New(i18n.Russian)
+
+	tests := []struct {
+		name string
+		kind Kind
+		body string
+		want bool
+	}{
+		{name: "шаблон по умолчанию", kind: KindBirthday, want: true},
+		{name: "напоминание по умолчанию", kind: KindReminder},
+		{name: "поле", kind: KindBirthday, body: "{{.Name}} {{.Greeting}}", want: true},
+		{name: "через $", kind: KindBirthday, body: "{{with .Name}}{{$.Greeting}}{{end}}", want: true},
+		{name: "в условии", kind: KindBirthday, body: "{{if .Greeting}}🎁{{end}}", want: true},
+		{name: "в define", kind: KindBirthday, body: `{{define "g"}}{{.Greeting}}{{end}}{{template "g" .}}`, want: true},
+		{name: "без поздравления", kind: KindBirthday, body: "С днем рождения, {{.Name}}!"},
+		{name: "сломанный шаблон заменяется шаблоном по умолчанию", kind: KindBirthday, body: "{{.Name", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UsesGreeting(ru, tt.kind, tt.body); got != tt.want {
+				t.Fatalf("UsesGreeting = %v, ожидалось %v", got, tt.want)
+			}
+		})
+	}
+}